@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/state"
+
+	"github.com/coder/serpent"
+	"gopkg.in/yaml.v3"
+)
+
+func avoidableDamageOption(path *string) serpent.Option {
+	return serpent.Option{
+		Name:        "Avoidable Damage",
+		Description: "A JSON or YAML file that replaces the catalog of avoidable damage. It maps encounter names to spell names, and the empty encounter name applies to every fight.",
+		Flag:        "avoidable-damage",
+		Value:       serpent.StringOf(path),
+	}
+}
+
+// avoidableDamage loads the avoidable damage file into a state option. No
+// options are returned without a file, so the default catalog is used.
+func avoidableDamage(path string) ([]state.Option, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read avoidable damage: %w", err)
+	}
+
+	// JSON is valid YAML, so one decoder handles both.
+	var catalog map[string][]string
+	if err := yaml.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("parse avoidable damage %q: %w", path, err)
+	}
+	return []state.Option{state.WithAvoidableDamage(catalog)}, nil
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/diagnostics"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/state"
+
+	"github.com/coder/serpent"
+)
+
+func combatTimeoutOption(timeout *time.Duration) serpent.Option {
+	return serpent.Option{
+		Name:        "Combat Timeout",
+		Description: "End a fight after this long without any damage or heals. 0 disables the timeout.",
+		Flag:        "combat-timeout",
+		Default:     state.DefaultCombatTimeout.String(),
+		Value:       serpent.DurationOf(timeout),
+	}
+}
+
+func diagnosticsOption(format *string) serpent.Option {
+	return serpent.Option{
+		Name:        "Diagnostics",
+		Description: "Print a report of the malformed, unparsed and skipped lines to stderr, either as text or json.",
+		Flag:        "diagnostics",
+		Value:       serpent.EnumOf(format, "", "text", "json"),
+	}
+}
+
+// reportDiagnostics warns if any lines were malformed or unparsed, and prints
+// the report in the format from the diagnostics option. Skipped lines are
+// expected, like the formatted duplicates of raw events, so they do not warn.
+func reportDiagnostics(i *serpent.Invocation, logger *slog.Logger, format string, report *diagnostics.Report) error {
+	if report.Malformed.Count > 0 || report.Unparsed.Count > 0 {
+		logger.Warn("Some lines could not be used",
+			slog.Int("malformed", report.Malformed.Count),
+			slog.Int("unparsed", report.Unparsed.Count),
+			slog.Int("skipped", report.SkippedTotal()),
+		)
+	}
+
+	switch format {
+	case "text":
+		_, err := fmt.Fprint(i.Stderr, report.String())
+		return err
+	case "json":
+		return report.WriteJSON(i.Stderr)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/diagnostics"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/export"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/export/parquetexport"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/export/sqlexport"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/state"
+
+	"github.com/coder/serpent"
+)
+
+func ExportCmd() *serpent.Command {
+	var (
+		combatTimeout time.Duration
+		diagFormat    string
+		merging       mergeFlags
+		format        string
+		outputPath    string
+	)
+
+	cmd := &serpent.Command{
+		Use:        "export <file>...",
+		Short:      "Export the parsed logs in a stable format for other tools",
+		Middleware: serpent.RequireRangeArgs(1, -1),
+		Options: append(serpent.OptionSet{
+			combatTimeoutOption(&combatTimeout),
+			{
+				Name:        "Format",
+				Description: "The export format. sqlite appends the log to a database with every damage, heal, aura and death event, so many logs can share one database. csv and parquet write every event as a row. sqlite and parquet require --output.",
+				Flag:        "format",
+				Default:     "json",
+				Value:       serpent.EnumOf(&format, "json", "sqlite", "csv", "parquet"),
+			},
+			{
+				Name:          "Output",
+				Description:   "Where to write the export. Defaults to stdout.",
+				Flag:          "output",
+				FlagShorthand: "o",
+				Value:         serpent.StringOf(&outputPath),
+			},
+			diagnosticsOption(&diagFormat),
+		}, merging.options()...),
+		Handler: func(i *serpent.Invocation) error {
+			logger := getLogger(i)
+
+			if (format == "sqlite" || format == "parquet") && outputPath == "" {
+				return fmt.Errorf("--output is required for the %s format", format)
+			}
+
+			events := export.NewEventLog()
+			var observe func(messages.Message)
+			if format != "json" {
+				observe = events.Record
+			}
+
+			diag := diagnostics.New()
+			final, err := parseFiles(i, logger, i.Args, &merging, diag, observe, state.WithCombatTimeout(combatTimeout))
+			if err != nil {
+				return err
+			}
+			if err := reportDiagnostics(i, logger, diagFormat, diag); err != nil {
+				return err
+			}
+
+			report := export.FromState(final)
+			if format == "sqlite" {
+				return sqlexport.Write(i.Context(), outputPath, report, events.Events(final))
+			}
+
+			var out io.Writer = i.Stdout
+			if outputPath != "" {
+				f, err := os.OpenFile(outputPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+				if err != nil {
+					return fmt.Errorf("opening output file %s: %w", outputPath, err)
+				}
+				defer func() { _ = f.Close() }()
+				out = f
+			}
+
+			switch format {
+			case "json":
+				return export.WriteJSON(out, report)
+			case "csv":
+				return export.WriteCSV(out, export.Rows(events.Events(final)))
+			case "parquet":
+				return parquetexport.Write(out, export.Rows(events.Events(final)))
+			default:
+				return fmt.Errorf("unknown format %q", format)
+			}
+		},
+	}
+
+	return cmd
+}
@@ -2,8 +2,12 @@ package cli
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"time"
 
+	"github.com/Emyrk/chronicle/golang/wowlogs/diagnostics"
 	"github.com/Emyrk/chronicle/golang/wowlogs/merge"
 
 	"github.com/coder/serpent"
@@ -12,12 +16,14 @@ import (
 func MergeCmd() *serpent.Command {
 	var (
 		outputPath string
+		diagFormat string
+		merging    mergeFlags
 	)
 
 	cmd := &serpent.Command{
-		Use:        "merge <file> <file>",
-		Middleware: serpent.RequireNArgs(2),
-		Options: []serpent.Option{
+		Use:        "merge <file>...",
+		Middleware: serpent.RequireRangeArgs(1, -1),
+		Options: append(serpent.OptionSet{
 			{
 				Name:          "Output Path",
 				Flag:          "output",
@@ -26,18 +32,20 @@ func MergeCmd() *serpent.Command {
 				Required:      false,
 				Value:         serpent.StringOf(&outputPath),
 			},
-		},
+			diagnosticsOption(&diagFormat),
+		}, merging.options()...),
 		Handler: func(i *serpent.Invocation) error {
 			ctx := i.Context()
 			logger := getLogger(i)
-			m := merge.NewMerger(logger)
+			diag := diagnostics.New()
+			opts := append(merging.mergerOptions(), merge.WithDiagnostics(diag))
+			m := merge.NewMerger(logger, opts...)
 
-			files, err := openFileReaders(i.Args[0], i.Args[1])
+			files, err := openFileReaders(i.Args...)
 			if err != nil {
 				return err
 			}
 			defer func() { closeFiles(files...) }()
-			first, second := files[0], files[1]
 
 			wr := i.Stdout
 			if outputPath != "" {
@@ -47,7 +55,11 @@ func MergeCmd() *serpent.Command {
 				}
 			}
 
-			return m.MergeLogs(ctx, first, second, wr)
+			if err := m.MergeLogs(ctx, wr, readers(files)...); err != nil {
+				return err
+			}
+			merging.log(logger)
+			return reportDiagnostics(i, logger, diagFormat, diag)
 		},
 	}
 	return cmd
@@ -72,3 +84,68 @@ func openFileReaders(paths ...string) ([]*os.File, error) {
 
 	return readers, nil
 }
+
+func readers(files []*os.File) []io.Reader {
+	rs := make([]io.Reader, 0, len(files))
+	for _, f := range files {
+		rs = append(rs, f)
+	}
+	return rs
+}
+
+// mergeFlags configure the stages between merging the files and parsing the
+// lines.
+type mergeFlags struct {
+	dedupeTolerance time.Duration
+	reorderWindow   time.Duration
+
+	dedupe  *merge.Dedupe
+	reorder *merge.Reorder
+}
+
+func (f *mergeFlags) options() serpent.OptionSet {
+	return serpent.OptionSet{
+		{
+			Name:        "Dedupe Tolerance",
+			Description: "Collapse the events that are in both the formatted and raw logs when they are at most this far apart, keeping the raw line. 0 disables the dedupe.",
+			Flag:        "dedupe-tolerance",
+			Default:     merge.DefaultDedupeTolerance.String(),
+			Value:       serpent.DurationOf(&f.dedupeTolerance),
+		},
+		{
+			Name:        "Reorder Window",
+			Description: "Sort lines that are out of order by at most this long, like after a lag spike. 0 disables the reorder.",
+			Flag:        "reorder-window",
+			Default:     merge.DefaultReorderWindow.String(),
+			Value:       serpent.DurationOf(&f.reorderWindow),
+		},
+	}
+}
+
+// mergerOptions creates the enabled stages.
+func (f *mergeFlags) mergerOptions() []merge.Option {
+	var opts []merge.Option
+	if f.reorderWindow > 0 {
+		f.reorder = merge.NewReorder(f.reorderWindow)
+		opts = append(opts, merge.WithReorder(f.reorder))
+	}
+	if f.dedupeTolerance > 0 {
+		f.dedupe = merge.NewDedupe(f.dedupeTolerance)
+		opts = append(opts, merge.WithDedupe(f.dedupe))
+	}
+	return opts
+}
+
+func (f *mergeFlags) log(logger *slog.Logger) {
+	if f.reorder != nil {
+		logger.Info("Reordered out of order lines",
+			slog.Int("reordered", f.reorder.Reordered),
+		)
+	}
+	if f.dedupe != nil {
+		logger.Info("Deduplicated formatted and raw events",
+			slog.Int("collapsed", f.dedupe.Collapsed),
+			slog.Int("unmatched", f.dedupe.Unmatched),
+		)
+	}
+}
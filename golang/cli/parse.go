@@ -5,58 +5,52 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"time"
 
+	"github.com/Emyrk/chronicle/golang/wowlogs/diagnostics"
+	"github.com/Emyrk/chronicle/golang/wowlogs/merge"
 	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser"
 	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/state"
 
 	"github.com/coder/serpent"
 )
 
 func ParseCmd() *serpent.Command {
+	var (
+		combatTimeout time.Duration
+		diagFormat    string
+		avoidablePath string
+		merging       mergeFlags
+	)
+
 	cmd := &serpent.Command{
-		Use:        "parse <file> <file>",
-		Middleware: serpent.RequireNArgs(2),
+		Use:        "parse <file>...",
+		Middleware: serpent.RequireRangeArgs(1, -1),
+		Options: append(serpent.OptionSet{
+			combatTimeoutOption(&combatTimeout),
+			avoidableDamageOption(&avoidablePath),
+			diagnosticsOption(&diagFormat),
+		}, merging.options()...),
 		Handler: func(i *serpent.Invocation) error {
-			ctx := i.Context()
 			logger := getLogger(i)
 
-			files, err := openFileReaders(i.Args[0], i.Args[1])
+			opts, err := avoidableDamage(avoidablePath)
 			if err != nil {
 				return err
 			}
-			defer func() { closeFiles(files...) }()
 
-			m := vanillaparser.Merger(logger)
-			liner, scan, err := m.LineScanner(ctx, files[0], files[1])
+			diag := diagnostics.New()
+			final, err := parseFiles(i, logger, i.Args, &merging, diag, nil, append(opts, state.WithCombatTimeout(combatTimeout))...)
 			if err != nil {
 				return err
 			}
-
-			p := vanillaparser.NewFromScanner(logger, liner, scan)
-			for {
-				if i.Context().Err() != nil {
-					return i.Context().Err()
-				}
-				msgs, err := p.Advance()
-				if err != nil {
-					if vanillaparser.IsFatalError(err) {
-						return fmt.Errorf("fatal parser error: %w", err)
-					}
-					if errors.Is(err, io.EOF) {
-						break
-					}
-					logger.Error("Error advancing parser", slog.String("error", err.Error()))
-				}
-				for _, msg := range msgs {
-					if up, ok := msg.(messages.UnparsedLine); ok {
-						logger.Warn("Unparsed line", slog.String("line", up.Content))
-					}
-				}
+			if err := reportDiagnostics(i, logger, diagFormat, diag); err != nil {
+				return err
 			}
 
-			state := p.State()
 			//fmt.Println("Final parser state:")
-			fmt.Println(state)
+			fmt.Println(final)
 
 			return nil
 		},
@@ -64,3 +58,51 @@ func ParseCmd() *serpent.Command {
 
 	return cmd
 }
+
+// parseFiles merges the log files and parses them into the final state. The
+// dropped lines are counted in diag. Every parsed message is passed to
+// observe, if it is not nil.
+func parseFiles(i *serpent.Invocation, logger *slog.Logger, paths []string, merging *mergeFlags, diag *diagnostics.Report, observe func(messages.Message), opts ...state.Option) (*state.State, error) {
+	files, err := openFileReaders(paths...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { closeFiles(files...) }()
+
+	m := vanillaparser.Merger(logger, append(merging.mergerOptions(), merge.WithDiagnostics(diag))...)
+	liner, scan, err := m.LineScanner(i.Context(), readers(files)...)
+	if err != nil {
+		return nil, err
+	}
+
+	p := vanillaparser.NewFromScanner(logger, liner, scan)
+	p.SetStateOptions(opts...)
+	p.SetDiagnostics(diag)
+	for {
+		if i.Context().Err() != nil {
+			return nil, i.Context().Err()
+		}
+		msgs, err := p.Advance()
+		if err != nil {
+			if vanillaparser.IsFatalError(err) {
+				return nil, fmt.Errorf("fatal parser error: %w", err)
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			logger.Error("Error advancing parser", slog.String("error", err.Error()))
+		}
+		for _, msg := range msgs {
+			if observe != nil {
+				observe(msg)
+			}
+			if up, ok := msg.(messages.UnparsedLine); ok {
+				logger.Warn("Unparsed line", slog.String("line", up.Content))
+			}
+		}
+	}
+
+	merging.log(logger)
+
+	return p.State(), nil
+}
@@ -25,6 +25,8 @@ func RootCmd() *serpent.Command {
 		versionCmd(),
 		MergeCmd(),
 		ParseCmd(),
+		SummaryCmd(),
+		ExportCmd(),
 		GuidCmd(),
 		SortCmd(),
 	)
@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"log/slog"
+	"math"
 	"os"
 	"path/filepath"
 	"time"
@@ -14,7 +15,9 @@ import (
 
 func SortCmd() *serpent.Command {
 	var (
-		outputPath string
+		outputPath   string
+		diagFormat   string
+		memoryBudget int64
 	)
 
 	cmd := &serpent.Command{
@@ -27,8 +30,23 @@ func SortCmd() *serpent.Command {
 				FlagShorthand: "o",
 				Value:         serpent.StringOf(&outputPath),
 			},
+			{
+				Name:        "Memory Budget",
+				Description: "How many MiB of lines to sort in memory. Larger logs are sorted in runs written to temp files, and merged. Must be at least 1.",
+				Flag:        "memory-budget",
+				Default:     fmt.Sprintf("%d", sorter.DefaultMemoryBudget>>20),
+				Value:       serpent.Int64Of(&memoryBudget),
+			},
+			diagnosticsOption(&diagFormat),
 		},
 		Handler: func(i *serpent.Invocation) error {
+			if memoryBudget <= 0 {
+				return fmt.Errorf("--memory-budget must be at least 1 MiB, got %d", memoryBudget)
+			}
+			if memoryBudget > math.MaxInt>>20 {
+				return fmt.Errorf("--memory-budget must be at most %d MiB, got %d", math.MaxInt>>20, memoryBudget)
+			}
+
 			sortMePath := i.Args[0]
 			ctx := i.Context()
 			logger := getLogger(i)
@@ -58,7 +76,7 @@ func SortCmd() *serpent.Command {
 				}
 			}
 
-			smry, err := sorter.SortLogs(ctx, logger, files[0], outFile)
+			smry, err := sorter.SortLogs(ctx, logger, files[0], outFile, sorter.WithMemoryBudget(int(memoryBudget)<<20))
 			if err != nil {
 				return fmt.Errorf("sorting logs: %w", err)
 			}
@@ -71,6 +89,9 @@ func SortCmd() *serpent.Command {
 				slog.String("duration", smry.Latest.Sub(smry.Earliest).String()),
 				slog.Bool("used_temp_file", usingTempFile),
 			)
+			if err := reportDiagnostics(i, logger, diagFormat, smry.Diagnostics); err != nil {
+				return err
+			}
 
 			if usingTempFile {
 				tmpPath := filepath.Join(os.TempDir(), fmt.Sprintf("chronicle_original_%d", time.Now().UnixMilli()))
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/diagnostics"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/state"
+
+	"github.com/coder/serpent"
+)
+
+func SummaryCmd() *serpent.Command {
+	var (
+		combatTimeout time.Duration
+		diagFormat    string
+		avoidablePath string
+		merging       mergeFlags
+		fightNumber   int64
+		from          string
+		to            string
+		top           int64
+	)
+
+	cmd := &serpent.Command{
+		Use:        "summary <file>...",
+		Short:      "Print a readable report of every fight",
+		Middleware: serpent.RequireRangeArgs(1, -1),
+		Options: append(serpent.OptionSet{
+			combatTimeoutOption(&combatTimeout),
+			avoidableDamageOption(&avoidablePath),
+			{
+				Name:        "Fight",
+				Description: "Only print the fight with this number, starting at 1.",
+				Flag:        "fight",
+				Value:       serpent.Int64Of(&fightNumber),
+			},
+			{
+				Name:        "From",
+				Description: "Only print fights that end after this time. Either 15:04, 15:04:05 or 2006-01-02 15:04:05.",
+				Flag:        "from",
+				Value:       serpent.StringOf(&from),
+			},
+			{
+				Name:        "To",
+				Description: "Only print fights that start before this time. Either 15:04, 15:04:05 or 2006-01-02 15:04:05.",
+				Flag:        "to",
+				Value:       serpent.StringOf(&to),
+			},
+			{
+				Name:        "Top",
+				Description: "How many players to list for damage and healing. 0 lists everyone.",
+				Flag:        "top",
+				Default:     fmt.Sprintf("%d", state.DefaultSummaryTop),
+				Value:       serpent.Int64Of(&top),
+			},
+			diagnosticsOption(&diagFormat),
+		}, merging.options()...),
+		Handler: func(i *serpent.Invocation) error {
+			logger := getLogger(i)
+
+			opts, err := avoidableDamage(avoidablePath)
+			if err != nil {
+				return err
+			}
+
+			diag := diagnostics.New()
+			final, err := parseFiles(i, logger, i.Args, &merging, diag, nil, append(opts, state.WithCombatTimeout(combatTimeout))...)
+			if err != nil {
+				return err
+			}
+			if err := reportDiagnostics(i, logger, diagFormat, diag); err != nil {
+				return err
+			}
+
+			fights := final.Fights
+			filter := state.FightFilter{Number: int(fightNumber)}
+			anchor := firstFightStart(fights)
+			if from != "" {
+				filter.From, err = parseFightTime(from, anchor)
+				if err != nil {
+					return fmt.Errorf("--from: %w", err)
+				}
+			}
+			if to != "" {
+				filter.To, err = parseFightTime(to, anchor)
+				if err != nil {
+					return fmt.Errorf("--to: %w", err)
+				}
+			}
+
+			selected := fights.Select(filter)
+			if len(selected) == 0 {
+				_, _ = fmt.Fprintln(i.Stdout, "No fights found")
+				return nil
+			}
+			for _, idx := range selected {
+				_, _ = fmt.Fprintf(i.Stdout, "=== Fight #%d: %s\n\n", idx+1, fights.Fights[idx].Summary(int(top)))
+			}
+			if consumables := final.ConsumablesSummary(); consumables != "" {
+				_, _ = fmt.Fprintf(i.Stdout, "=== Consumables\n%s\n", consumables)
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// firstFightStart is the start of the first fight in the log, or the zero time.
+func firstFightStart(fights *state.Fights) time.Time {
+	for _, fight := range fights.Fights {
+		if fight.IsStarted() {
+			return fight.Start.Date()
+		}
+	}
+	return time.Time{}
+}
+
+// parseFightTime parses a date and time, or just a time of day. A time of day
+// is on the same date as the anchor, or the next day if it is more than 12
+// hours before the anchor, so raids that go past midnight can be selected.
+func parseFightTime(value string, anchor time.Time) (time.Time, error) {
+	loc := anchor.Location()
+	if ts, err := time.ParseInLocation(time.DateTime, value, loc); err == nil {
+		return ts, nil
+	}
+
+	var clock time.Time
+	var err error
+	for _, layout := range []string{time.TimeOnly, "15:04"} {
+		clock, err = time.ParseInLocation(layout, value, loc)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", value)
+	}
+
+	ts := time.Date(anchor.Year(), anchor.Month(), anchor.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, loc)
+	if anchor.Sub(ts) > 12*time.Hour {
+		ts = ts.AddDate(0, 0, 1)
+	}
+	return ts, nil
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -11,7 +10,9 @@ import (
 	"os"
 	"syscall/js"
 
+	"github.com/Emyrk/chronicle/golang/wowlogs/merge"
 	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/export"
 )
 
 func main() {
@@ -44,7 +45,10 @@ func parseLogsFunc(this js.Value, args []js.Value) interface{} {
 	}))
 
 	// Create the merger and parser
-	m := vanillaparser.Merger(logger)
+	m := vanillaparser.Merger(logger,
+		merge.WithReorder(merge.NewReorder(merge.DefaultReorderWindow)),
+		merge.WithDedupe(merge.NewDedupe(merge.DefaultDedupeTolerance)),
+	)
 	liner, scan, err := m.LineScanner(context.Background(), combatLogReader, rawCombatLogReader)
 	if err != nil {
 		return map[string]interface{}{
@@ -66,11 +70,9 @@ func parseLogsFunc(this js.Value, args []js.Value) interface{} {
 		}
 	}
 
-	// Get the final state
-	state := p.State()
-
-	// Convert state to JSON
-	stateJSON, err := json.MarshalIndent(state, "", "  ")
+	// Convert the final state to the versioned export model
+	var stateJSON bytes.Buffer
+	err = export.WriteJSON(&stateJSON, export.FromState(p.State()))
 	if err != nil {
 		return map[string]interface{}{
 			"error": fmt.Sprintf("Failed to marshal state: %v", err),
@@ -79,6 +81,6 @@ func parseLogsFunc(this js.Value, args []js.Value) interface{} {
 
 	return map[string]interface{}{
 		"success": true,
-		"state":   string(stateJSON),
+		"state":   stateJSON.String(),
 	}
 }
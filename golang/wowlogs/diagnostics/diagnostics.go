@@ -0,0 +1,135 @@
+// Package diagnostics counts the lines of a log that could not be used, so a
+// half broken log does not go unnoticed.
+package diagnostics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// DefaultSamples is how many lines are kept as samples for each problem.
+const DefaultSamples = 5
+
+// Report collects the lines dropped by merge, sort and parse. A nil report
+// ignores every line added and reads as empty, so callers do not need to check.
+type Report struct {
+	// MaxSamples is the most lines kept per problem. 0 keeps none.
+	MaxSamples int `json:"-"`
+
+	// Malformed are lines without a valid timestamp.
+	Malformed Lines `json:"malformed"`
+	// Unparsed are lines with content the parser does not understand.
+	Unparsed Lines `json:"unparsed"`
+	// Skipped are the lines the parser chose to ignore, by the reason.
+	Skipped map[string]*Lines `json:"skipped"`
+}
+
+// Lines is the number of lines with a problem and a few of them.
+type Lines struct {
+	Count   int      `json:"count"`
+	Samples []string `json:"samples,omitempty"`
+}
+
+func New() *Report {
+	return &Report{
+		MaxSamples: DefaultSamples,
+		Skipped:    make(map[string]*Lines),
+	}
+}
+
+func (r *Report) AddMalformed(line string) {
+	if r == nil {
+		return
+	}
+	r.add(&r.Malformed, line)
+}
+
+func (r *Report) AddUnparsed(line string) {
+	if r == nil {
+		return
+	}
+	r.add(&r.Unparsed, line)
+}
+
+func (r *Report) AddSkipped(reason, line string) {
+	if r == nil {
+		return
+	}
+	skipped, ok := r.Skipped[reason]
+	if !ok {
+		skipped = &Lines{}
+		r.Skipped[reason] = skipped
+	}
+	r.add(skipped, line)
+}
+
+func (r *Report) add(l *Lines, line string) {
+	l.Count++
+	if len(l.Samples) < r.MaxSamples {
+		l.Samples = append(l.Samples, line)
+	}
+}
+
+// SkippedTotal is the number of skipped lines for every reason.
+func (r *Report) SkippedTotal() int {
+	if r == nil {
+		return 0
+	}
+	total := 0
+	for _, skipped := range r.Skipped {
+		total += skipped.Count
+	}
+	return total
+}
+
+// Empty is true if every line was used.
+func (r *Report) Empty() bool {
+	if r == nil {
+		return true
+	}
+	return r.Malformed.Count == 0 && r.Unparsed.Count == 0 && r.SkippedTotal() == 0
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("encode diagnostics: %w", err)
+	}
+	return nil
+}
+
+func (r *Report) String() string {
+	if r == nil {
+		return "Diagnostics: none\n"
+	}
+	var str strings.Builder
+	str.WriteString("Diagnostics\n")
+	writeLines(&str, "Malformed timestamps", r.Malformed)
+	writeLines(&str, "Unparsed lines", r.Unparsed)
+
+	str.WriteString(fmt.Sprintf("Skipped lines: %d\n", r.SkippedTotal()))
+	reasons := slices.SortedFunc(maps.Keys(r.Skipped), func(a, b string) int {
+		if r.Skipped[a].Count != r.Skipped[b].Count {
+			return r.Skipped[b].Count - r.Skipped[a].Count
+		}
+		return strings.Compare(a, b)
+	})
+	for _, reason := range reasons {
+		writeLines(&str, "  "+reason, *r.Skipped[reason])
+	}
+	return str.String()
+}
+
+func writeLines(str *strings.Builder, name string, l Lines) {
+	indent := strings.Repeat(" ", len(name)-len(strings.TrimLeft(name, " ")))
+	str.WriteString(fmt.Sprintf("%s: %d\n", name, l.Count))
+	for _, sample := range l.Samples {
+		str.WriteString(fmt.Sprintf("%s  %s\n", indent, sample))
+	}
+}
@@ -0,0 +1,62 @@
+package diagnostics_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/diagnostics"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+
+	r := diagnostics.New()
+	r.MaxSamples = 2
+	require.True(t, r.Empty())
+
+	r.AddMalformed("garbage")
+	for _, line := range []string{"a", "b", "c"} {
+		r.AddSkipped("empty line", line)
+	}
+	r.AddSkipped("Heal: not using guids", "d")
+	r.AddUnparsed("11/18 07:20:42.731  Something new")
+
+	require.False(t, r.Empty())
+	require.Equal(t, 1, r.Malformed.Count)
+	require.Equal(t, 4, r.SkippedTotal())
+	require.Equal(t, diagnostics.Lines{Count: 3, Samples: []string{"a", "b"}}, *r.Skipped["empty line"])
+
+	require.Equal(t, `Diagnostics
+Malformed timestamps: 1
+  garbage
+Unparsed lines: 1
+  11/18 07:20:42.731  Something new
+Skipped lines: 4
+  empty line: 3
+    a
+    b
+  Heal: not using guids: 1
+    d
+`, r.String())
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteJSON(&buf))
+	var decoded diagnostics.Report
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, r.Malformed, decoded.Malformed)
+	require.Equal(t, r.Skipped, decoded.Skipped)
+
+	t.Run("Nil", func(t *testing.T) {
+		t.Parallel()
+
+		var r *diagnostics.Report
+		r.AddMalformed("garbage")
+		r.AddUnparsed("line")
+		r.AddSkipped("reason", "line")
+		require.True(t, r.Empty())
+		require.Zero(t, r.SkippedTotal())
+		require.NotEmpty(t, r.String())
+	})
+}
@@ -0,0 +1,48 @@
+package diagnostics_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Emyrk/chronicle/golang/internal/testutil"
+	"github.com/Emyrk/chronicle/golang/wowlogs/diagnostics"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	log := `11/18 07:20:42.000  UNIT_INFO: 18.11.25 07:20:42&0x00000000000E8AB6&1&Mooshuggah&1&nil
+11/18 07:20:43.000  CAST: Mooshuggah casts Flurry(16257)(Rank 1) on Mooshuggah.
+not a log line
+11/18 07:20:44.000  Mooshuggah does something nobody has logged before.
+11/18 07:20:45.000  CAST: 0x00000000000E8AB6(Mooshuggah) casts Flurry(16257)(Rank 1) on 0x00000000000E8AB6(Mooshuggah).`
+
+	logger := testutil.Logger(t)
+	diag := diagnostics.New()
+	liner, scan, err := vanillaparser.Merger(logger).LineScanner(t.Context(), strings.NewReader(log))
+	require.NoError(t, err)
+
+	p := vanillaparser.NewFromScanner(logger, liner, scan)
+	p.SetDiagnostics(diag)
+	for {
+		_, err := p.Advance()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, diagnostics.Lines{
+		Count:   1,
+		Samples: []string{"11/18 07:20:44.000  Mooshuggah does something nobody has logged before."},
+	}, diag.Unparsed)
+	require.Equal(t, 1, diag.SkippedTotal())
+	require.Equal(t, diagnostics.Lines{
+		Count:   1,
+		Samples: []string{"11/18 07:20:43.000  CAST: Mooshuggah casts Flurry(16257)(Rank 1) on Mooshuggah."},
+	}, *diag.Skipped["castv2: not using guids"])
+}
@@ -1,21 +1,45 @@
 package lines
 
 import (
+	"bufio"
 	"errors"
+	"io"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+
 	"github.com/coder/quartz"
 )
 
 const (
 	LogDateFormat = "01/2 15:04:05.000"
+
+	// peekSize is how far ahead PeekYear looks for an addon timestamp.
+	peekSize = 256 * 1024
+	// rollover is how far apart two lines can be before the year is assumed
+	// to have changed between them.
+	rollover = 183 * 24 * time.Hour
 )
 
+// addonDate is the full timestamp at the start of addon lines, like
+// `UNIT_INFO: 18.11.25 07:21:02&...`.
+var addonDate = regexp.MustCompile(`^[A-Z_]+: (\d{2}\.\d{2}\.\d{2} \d{2}:\d{2}:\d{2})&`)
+
+// Liner splits the timestamp from log lines. The log timestamps do not have a
+// year, so it is taken from the addon timestamps when there are any, and
+// guessed from the clock otherwise. The year changes when the log crosses
+// New Year.
 type Liner struct {
 	Year  int
 	clock quartz.Clock
+	// last is the timestamp of the previous line.
+	last time.Time
+	// anchor is the log timestamp of the addon line PeekYear took the year
+	// from.
+	anchor time.Time
 }
 
 func NewLiner() *Liner {
@@ -87,6 +111,31 @@ func (l *Liner) guessYear(line string) error {
 	//}
 }
 
+// PeekYear reads ahead in r for an addon timestamp to set the year, so the
+// lines before the first addon line get the right year too. The returned
+// reader must be used in place of r.
+func (l *Liner) PeekYear(r io.Reader) io.Reader {
+	br := bufio.NewReaderSize(r, peekSize)
+	if l.Year != 0 {
+		return br
+	}
+
+	// The error is returned again by the next read.
+	data, _ := br.Peek(peekSize)
+	for _, line := range strings.Split(string(data), "\n") {
+		ts, content, err := l.parse(l.clock.Now().Year(), strings.TrimRight(line, "\r"))
+		if err != nil {
+			continue
+		}
+		if addon, ok := parseAddonDate(content); ok {
+			l.Year = closestYear(ts, addon)
+			l.anchor = ts
+			break
+		}
+	}
+	return br
+}
+
 func (l *Liner) Line(line string) (time.Time, string, error) {
 	if l.Year == 0 {
 		err := l.guessYear(line)
@@ -95,7 +144,68 @@ func (l *Liner) Line(line string) (time.Time, string, error) {
 		}
 	}
 
-	return l.parse(l.Year, line)
+	ts, content, err := l.parse(l.Year, line)
+	if err != nil {
+		return ts, content, err
+	}
+
+	year := l.Year
+	if addon, ok := parseAddonDate(content); ok {
+		year = closestYear(ts, addon)
+	} else if l.last.IsZero() && !l.anchor.IsZero() {
+		// The lines before the anchor are from the previous year when the
+		// log starts in December and the first addon line is in January.
+		if ts.Month() > l.anchor.Month() {
+			year--
+		}
+	} else if !l.last.IsZero() {
+		// Lines from different files can be read out of order, so the year
+		// can go back as well.
+		switch {
+		case l.last.Sub(ts) > rollover:
+			year++
+		case ts.Sub(l.last) > rollover:
+			year--
+		}
+	}
+
+	if year != l.Year {
+		l.Year = year
+		ts, content, err = l.parse(l.Year, line)
+		if err != nil {
+			return ts, content, err
+		}
+	}
+	l.last = ts
+	return ts, content, nil
+}
+
+// parseAddonDate returns the full timestamp of an addon line.
+func parseAddonDate(content string) (time.Time, bool) {
+	match := addonDate.FindStringSubmatch(content)
+	if match == nil {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse(types.AddonDateFormat, match[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts, true
+}
+
+// closestYear is the year that puts the log timestamp closest to the addon
+// timestamp. They can be on different sides of New Year, as the log and the
+// addon do not use the same clock.
+func closestYear(ts, addon time.Time) int {
+	best, bestDiff := addon.Year(), time.Duration(-1)
+	for year := addon.Year() - 1; year <= addon.Year()+1; year++ {
+		candidate := time.Date(year, ts.Month(), ts.Day(), ts.Hour(), ts.Minute(), ts.Second(), 0, time.UTC)
+		diff := candidate.Sub(addon).Abs()
+		if bestDiff < 0 || diff < bestDiff {
+			best, bestDiff = year, diff
+		}
+	}
+	return best
 }
 
 func (l *Liner) parse(year int, line string) (time.Time, string, error) {
@@ -1,6 +1,7 @@
 package lines_test
 
 import (
+	"bufio"
 	"strings"
 	"testing"
 	"time"
@@ -106,3 +107,106 @@ func TestLinerRoundTrip(t *testing.T) {
 		require.Equal(t, line, serialized, content)
 	}
 }
+
+func TestAddonYear(t *testing.T) {
+	t.Parallel()
+
+	clock := quartz.NewMock(t)
+	clock.Set(time.Date(2026, 11, 19, 0, 0, 0, 0, time.UTC))
+
+	// Parsed two years later, the clock guesses 2026
+	log := `11/18 07:20:42.699  Mooshuggah casts Flurry.
+11/18 07:20:42.700  COMBATANT_GUID: 18.11.24 07:20:42&Maldrissa&0x00000000000EB167
+11/18 07:20:42.747  ZONE_INFO: 18.11.24 07:20:42&hillsbrad foothills&0`
+
+	t.Run("PeekYear", func(t *testing.T) {
+		t.Parallel()
+
+		l := lines.NewLiner()
+		l.SetClock(clock)
+		sc := bufio.NewScanner(l.PeekYear(strings.NewReader(log)))
+		require.Equal(t, 2024, l.GetYear())
+
+		count := 0
+		for sc.Scan() {
+			ts, _, err := l.Line(sc.Text())
+			require.NoError(t, err)
+			require.Equal(t, 2024, ts.Year())
+			count++
+		}
+		require.Equal(t, 3, count)
+	})
+
+	t.Run("PeekAcrossNewYear", func(t *testing.T) {
+		t.Parallel()
+
+		// The first addon line is after midnight
+		log := `12/31 23:59:58.000  Irontooth hits Gray Bear for 20.
+12/31 23:59:59.000  Irontooth hits Gray Bear for 20.
+01/1 00:00:01.000  UNIT_INFO: 01.01.25 00:00:01&0x00000000000F5F4B&1&Irontooth&1&0x0000000000000000
+01/1 00:00:02.000  Irontooth hits Gray Bear for 20.`
+
+		l := lines.NewLiner()
+		l.SetClock(clock)
+		sc := bufio.NewScanner(l.PeekYear(strings.NewReader(log)))
+		require.Equal(t, 2025, l.GetYear())
+
+		var years []int
+		for sc.Scan() {
+			ts, _, err := l.Line(sc.Text())
+			require.NoError(t, err)
+			years = append(years, ts.Year())
+		}
+		require.Equal(t, []int{2024, 2024, 2025, 2025}, years)
+	})
+
+	t.Run("MidStream", func(t *testing.T) {
+		t.Parallel()
+
+		l := lines.NewLiner()
+		l.SetClock(clock)
+		for i, line := range strings.Split(log, "\n") {
+			ts, _, err := l.Line(line)
+			require.NoError(t, err)
+			if i == 0 {
+				require.Equal(t, 2026, ts.Year(), "guessed from the clock")
+				continue
+			}
+			require.Equal(t, 2024, ts.Year())
+		}
+	})
+
+	t.Run("AcrossMidnight", func(t *testing.T) {
+		t.Parallel()
+
+		// The addon clock is already in the new year
+		l := lines.NewLiner()
+		l.SetClock(clock)
+		ts, _, err := l.Line("12/31 23:59:59.900  UNIT_INFO: 01.01.25 00:00:00&0x00000000000F5F4B&1&Irontooth&1&0x0000000000000000")
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2024, 12, 31, 23, 59, 59, 900_000_000, time.UTC), ts)
+	})
+}
+
+func TestYearRollover(t *testing.T) {
+	t.Parallel()
+
+	clock := quartz.NewMock(t)
+	clock.Set(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	l := lines.NewLiner()
+	l.SetClock(clock)
+
+	expect := func(line string, want time.Time) {
+		t.Helper()
+		ts, _, err := l.Line(line)
+		require.NoError(t, err)
+		require.Equal(t, want, ts)
+	}
+
+	expect("12/31 23:59:58.000  Irontooth hits Gray Bear for 20.", time.Date(2025, 12, 31, 23, 59, 58, 0, time.UTC))
+	expect("01/1 00:00:01.000  Irontooth hits Gray Bear for 20.", time.Date(2026, 1, 1, 0, 0, 1, 0, time.UTC))
+	// A second file that is a little behind
+	expect("12/31 23:59:59.000  Irontooth hits Gray Bear for 20.", time.Date(2025, 12, 31, 23, 59, 59, 0, time.UTC))
+	expect("01/1 00:00:02.000  Irontooth hits Gray Bear for 20.", time.Date(2026, 1, 1, 0, 0, 2, 0, time.UTC))
+}
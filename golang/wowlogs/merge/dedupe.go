@@ -0,0 +1,229 @@
+package merge
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/unitinfo"
+)
+
+// DefaultDedupeTolerance is how far apart the same event can be in the
+// formatted and raw logs.
+const DefaultDedupeTolerance = 500 * time.Millisecond
+
+// unknownName is the name the client logs for units it has not seen.
+const unknownName = "Unknown"
+
+var (
+	// guidToken is a GUID in a raw log line, with the name if the client knew it.
+	guidToken = regexp.MustCompile(`0x[0-9A-Fa-f]{16}(?:\(([^)]*)\))?`)
+	// unpaired are the addon lines that are only in the raw log, so they never
+	// have a formatted pair.
+	unpaired = []string{unitinfo.PrefixUnitInfo, "COMBATANT_GUID:"}
+)
+
+// Dedupe collapses events that are in both the formatted and the raw log.
+// The formatted log has unit names, the raw log has GUIDs, and both log the
+// same combat events. A raw line is matched to a formatted line by replacing
+// every GUID with the unit name. The raw line is kept, as the GUID is needed
+// to tell units with the same name apart.
+type Dedupe struct {
+	// Tolerance is how far apart the two lines of a pair can be.
+	Tolerance time.Duration
+
+	// Collapsed is the number of pairs where the formatted line was dropped.
+	Collapsed int
+	// Unmatched is the number of raw lines with a GUID that had no formatted
+	// line. Either a name in the line was not known, or the formatted log is
+	// missing the event.
+	Unmatched int
+
+	names   map[string]string
+	pending []*dedupeLine
+	last    time.Time
+}
+
+type dedupeLineKind int
+
+const (
+	dedupeOther dedupeLineKind = iota
+	dedupeRaw
+	dedupeFormatted
+)
+
+type dedupeLine struct {
+	ts      time.Time
+	content string
+	kind    dedupeLineKind
+	// named is the raw line with names instead of GUIDs. Empty if a name is
+	// not known.
+	named   string
+	matched bool
+	dropped bool
+}
+
+func NewDedupe(tolerance time.Duration) *Dedupe {
+	return &Dedupe{
+		Tolerance: tolerance,
+		names:     make(map[string]string),
+	}
+}
+
+// WithDedupe collapses the events that are in both the formatted and raw
+// logs. The counts are kept on the Dedupe.
+func WithDedupe(d *Dedupe) Option {
+	return func(m *Merger) {
+		m.dedupe = d
+	}
+}
+
+// scan wraps the in order lines of next. Lines are held back until no line
+// within the tolerance can still arrive.
+func (d *Dedupe) scan(next Scan) Scan {
+	done := false
+	return func() (time.Time, string, error) {
+		for {
+			if ts, content, ok := d.pop(done); ok {
+				return ts, content, nil
+			}
+			if done {
+				return time.Time{}, "", io.EOF
+			}
+
+			ts, content, err := next()
+			if errors.Is(err, io.EOF) {
+				done = true
+				continue
+			}
+			if err != nil {
+				return time.Time{}, "", err
+			}
+			d.push(ts, content)
+		}
+	}
+}
+
+// push adds the next line, in timestamp order.
+func (d *Dedupe) push(ts time.Time, content string) {
+	d.last = ts
+	d.learn(content)
+
+	line := &dedupeLine{ts: ts, content: content, kind: dedupeFormatted}
+	switch {
+	case isUnpaired(content):
+		line.kind = dedupeOther
+	case guidToken.MatchString(content):
+		line.kind = dedupeRaw
+		line.named = d.name(content)
+	}
+
+	if line.kind != dedupeOther {
+		d.match(line)
+	}
+	d.pending = append(d.pending, line)
+}
+
+// match pairs the line with the oldest unmatched pending line of the other
+// log.
+func (d *Dedupe) match(line *dedupeLine) {
+	for _, other := range d.pending {
+		if other.matched || other.kind == dedupeOther || other.kind == line.kind {
+			continue
+		}
+		if line.ts.Sub(other.ts) > d.Tolerance {
+			continue
+		}
+
+		formatted, raw := other, line
+		if other.kind == dedupeRaw {
+			formatted, raw = line, other
+		}
+		if raw.named == "" || raw.named != formatted.content {
+			continue
+		}
+
+		other.matched = true
+		line.matched = true
+		formatted.dropped = true
+		d.Collapsed++
+		return
+	}
+}
+
+// pop returns the oldest line that can no longer be matched. When flush is
+// true, every pending line is returned.
+func (d *Dedupe) pop(flush bool) (time.Time, string, bool) {
+	for len(d.pending) > 0 {
+		line := d.pending[0]
+		if !flush && d.last.Sub(line.ts) <= d.Tolerance {
+			return time.Time{}, "", false
+		}
+		d.pending[0] = nil
+		d.pending = d.pending[1:]
+
+		if line.dropped {
+			continue
+		}
+		if line.kind == dedupeRaw && !line.matched {
+			d.Unmatched++
+		}
+		return line.ts, line.content, true
+	}
+	return time.Time{}, "", false
+}
+
+func isUnpaired(content string) bool {
+	for _, prefix := range unpaired {
+		if strings.HasPrefix(content, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// learn keeps the names from UNIT_INFO lines, and from raw tokens like
+// 0x00000000000E8AB6(Mooshuggah). They name the bare GUIDs of later lines.
+func (d *Dedupe) learn(content string) {
+	if _, ok := unitinfo.IsUnitInfo(content); ok {
+		info, err := unitinfo.ParseUnitInfo(content)
+		if err == nil && info.Name != "" {
+			d.names[info.Guid.String()] = info.Name
+		}
+		return
+	}
+
+	for _, match := range guidToken.FindAllStringSubmatch(content, -1) {
+		if match[1] != "" && match[1] != unknownName {
+			d.names[guidKey(match[0])] = match[1]
+		}
+	}
+}
+
+// name replaces the GUIDs in a raw line with the unit names. A token uses the
+// name written next to it, as that is the name in the formatted line. Returns
+// empty if the name of a bare GUID is not known.
+func (d *Dedupe) name(content string) string {
+	known := true
+	named := guidToken.ReplaceAllStringFunc(content, func(token string) string {
+		if i := strings.IndexByte(token, '('); i >= 0 {
+			return token[i+1 : len(token)-1]
+		}
+		name, ok := d.names[guidKey(token)]
+		if !ok {
+			known = false
+		}
+		return name
+	})
+	if !known {
+		return ""
+	}
+	return named
+}
+
+// guidKey is the GUID of a token in the same form as guid.GUID.String.
+func guidKey(token string) string {
+	return "0x" + strings.ToUpper(token[2:18])
+}
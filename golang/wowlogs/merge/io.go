@@ -9,7 +9,7 @@ import (
 )
 
 func FromIOReader(lines *lines.Liner, m io.Reader) Scan {
-	scanner := bufio.NewScanner(m)
+	scanner := bufio.NewScanner(lines.PeekYear(m))
 	return func() (time.Time, string, error) {
 		if !scanner.Scan() {
 			return time.Time{}, "", io.EOF
@@ -2,6 +2,7 @@ package merge
 
 import (
 	"bufio"
+	"container/heap"
 	"context"
 	"errors"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"log/slog"
 	"time"
 
+	"github.com/Emyrk/chronicle/golang/wowlogs/diagnostics"
 	"github.com/Emyrk/chronicle/golang/wowlogs/lines"
 )
 
@@ -17,13 +19,16 @@ type Scan func() (time.Time, string, error)
 type MiddleWare func(ts time.Time, content string) bool
 type Option func(m *Merger)
 
-// Merger merges 2 log files and sorts by the timestamps.
+// Merger merges log files and sorts by the timestamps.
 // TODO: Add a parser in the middle to get rid of lines we do not want.
 // Also add a parser in the middle to capture some state and log additional lines at the start
 // for things like combatant info.
 type Merger struct {
-	logger *slog.Logger
-	mw     []MiddleWare
+	logger  *slog.Logger
+	mw      []MiddleWare
+	dedupe  *Dedupe
+	reorder *Reorder
+	diag    *diagnostics.Report
 }
 
 func NewMerger(logger *slog.Logger, opts ...Option) *Merger {
@@ -43,20 +48,40 @@ func WithMiddleWare(mw MiddleWare) Option {
 	}
 }
 
-func (m *Merger) LineScanner(ctx context.Context, formatted io.Reader, raw io.Reader) (*lines.Liner, Scan, error) {
-	f := bufio.NewScanner(formatted)
-	r := bufio.NewScanner(raw)
+// WithDiagnostics counts the lines that are dropped because the timestamp is
+// malformed.
+func WithDiagnostics(report *diagnostics.Report) Option {
+	return func(m *Merger) {
+		m.diag = report
+	}
+}
+
+// LineScanner merges any number of log files, like the formatted and raw logs
+// of one or more recording clients, into a single scan in timestamp order.
+func (m *Merger) LineScanner(ctx context.Context, readers ...io.Reader) (*lines.Liner, Scan, error) {
 	l := lines.NewLiner()
+	scanners := make([]*bufio.Scanner, 0, len(readers))
+	for _, r := range readers {
+		scanners = append(scanners, bufio.NewScanner(l.PeekYear(r)))
+	}
 
-	merger, err := newInOrderMerger(ctx, l, f, r)
+	merger, err := newInOrderMerger(ctx, l, m.diag, scanners...)
 	if err != nil {
 		return l, nil, fmt.Errorf("create merger: %w", err)
 	}
 
+	next := Scan(merger.next)
+	if m.reorder != nil {
+		next = m.reorder.scan(next)
+	}
+	if m.dedupe != nil {
+		next = m.dedupe.scan(next)
+	}
+
 	return l, func() (time.Time, string, error) {
 	LineLoop:
 		for {
-			ts, content, err := merger.next()
+			ts, content, err := next()
 			if err != nil {
 				return time.Time{}, "", err
 			}
@@ -72,8 +97,9 @@ func (m *Merger) LineScanner(ctx context.Context, formatted io.Reader, raw io.Re
 	}, nil
 }
 
-func (m *Merger) MergeLogs(ctx context.Context, formatted io.Reader, raw io.Reader, writer io.Writer) error {
-	l, scan, err := m.LineScanner(ctx, formatted, raw)
+// MergeLogs writes the merged lines of all the readers to the writer.
+func (m *Merger) MergeLogs(ctx context.Context, writer io.Writer, readers ...io.Reader) error {
+	l, scan, err := m.LineScanner(ctx, readers...)
 	if err != nil {
 		return fmt.Errorf("create line scanner: %w", err)
 	}
@@ -101,109 +127,115 @@ func (m *Merger) MergeLogs(ctx context.Context, formatted io.Reader, raw io.Read
 }
 
 type logFile struct {
-	Scanner  *bufio.Scanner
+	Scanner *bufio.Scanner
+	// index is the position of the file in the merger inputs.
+	index    int
 	lastTS   time.Time
 	lastLine string
 	done     bool
 }
 
-type logLine struct {
-	lastTS   time.Time
-	lastLine string
+// fileHeap orders the files by the timestamp of their next line. Lines with
+// the same timestamp are taken from the later file first, so the raw log comes
+// before the formatted log.
+type fileHeap []*logFile
+
+func (h fileHeap) Len() int { return len(h) }
+func (h fileHeap) Less(i, j int) bool {
+	if h[i].lastTS.Equal(h[j].lastTS) {
+		return h[i].index > h[j].index
+	}
+	return h[i].lastTS.Before(h[j].lastTS)
+}
+func (h fileHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *fileHeap) Push(x any)   { *h = append(*h, x.(*logFile)) }
+func (h *fileHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
 }
 
+// inOrderMerger is a k-way merge of log files. Each file must already be in
+// timestamp order.
 type inOrderMerger struct {
 	ctx   context.Context
 	liner *lines.Liner
-	Sets  [2]logFile
+	Sets  []*logFile
+	// pending are the files with a line left, the next line is at the top.
+	pending fileHeap
 
-	failedLines []string
+	diag *diagnostics.Report
 }
 
-func newInOrderMerger(ctx context.Context, l *lines.Liner, a, b *bufio.Scanner) (*inOrderMerger, error) {
+func newInOrderMerger(ctx context.Context, l *lines.Liner, diag *diagnostics.Report, scanners ...*bufio.Scanner) (*inOrderMerger, error) {
 	i := &inOrderMerger{
 		ctx:   ctx,
 		liner: l,
-		Sets: [2]logFile{
-			{Scanner: a},
-			{Scanner: b},
-		},
+		diag:  diag,
 	}
 
-	var err error
-	_, _, err = i.advance(0)
-	if err != nil {
-		return nil, fmt.Errorf("advance a: %w", err)
-	}
-
-	_, _, err = i.advance(1)
-	if err != nil {
-		return nil, fmt.Errorf("advance b: %w", err)
+	for index, scanner := range scanners {
+		set := &logFile{Scanner: scanner, index: index}
+		i.Sets = append(i.Sets, set)
+		if err := i.load(set); err != nil {
+			return nil, fmt.Errorf("advance file %d: %w", index, err)
+		}
+		if !set.done {
+			i.pending = append(i.pending, set)
+		}
 	}
+	heap.Init(&i.pending)
 
 	return i, nil
 }
 
 func (i *inOrderMerger) next() (time.Time, string, error) {
-	a := i.Sets[0]
-	b := i.Sets[1]
-	if a.done && b.done {
+	if len(i.pending) == 0 {
 		return time.Time{}, "", io.EOF
 	}
 
-	if a.done {
-		return i.advance(1)
-	}
-	if b.done {
-		return i.advance(0)
-	}
-
-	if a.lastTS.Before(b.lastTS) {
-		return i.advance(0)
-	}
-	return i.advance(1)
-}
-
-func (i *inOrderMerger) advance(index int) (time.Time, string, error) {
-	set := i.Sets[index]
+	set := i.pending[0]
 	ts, cnt := set.lastTS, set.lastLine
+	if err := i.load(set); err != nil {
+		return time.Time{}, "", err
+	}
 	if set.done {
-		return time.Time{}, "", io.EOF
+		heap.Pop(&i.pending)
+	} else {
+		heap.Fix(&i.pending, 0)
 	}
 
-	var nTs time.Time
-	var nl string
-	var err error
+	return ts, cnt, nil
+}
 
+// load reads the next parsable line of the file, or marks it as done.
+func (i *inOrderMerger) load(set *logFile) error {
 	for {
 		if i.ctx.Err() != nil {
-			return time.Time{}, "", i.ctx.Err()
+			return i.ctx.Err()
 		}
 
 		if !set.Scanner.Scan() {
-			lastTS, lastLine := set.lastTS, set.lastLine
-
 			set.done = true
 			set.lastTS = time.Time{}
 			set.lastLine = ""
-			i.Sets[index] = set
-			return lastTS, lastLine, nil
+			return nil
 		}
 
 		line := set.Scanner.Text()
-		nTs, nl, err = i.liner.Line(line)
+		ts, content, err := i.liner.Line(line)
 		if err != nil {
 			if errors.Is(err, io.EOF) {
-				return time.Time{}, "", io.EOF
+				return io.EOF
 			}
-			i.failedLines = append(i.failedLines, line)
+			i.diag.AddMalformed(line)
 			continue
 		}
-		break
-	}
-	set.lastTS = nTs
-	set.lastLine = nl
-	i.Sets[index] = set
 
-	return ts, cnt, nil
+		set.lastTS = ts
+		set.lastLine = content
+		return nil
+	}
 }
@@ -3,11 +3,14 @@ package merge_test
 import (
 	"bufio"
 	"bytes"
+	"errors"
+	"io"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/Emyrk/chronicle/golang/internal/testutil"
+	"github.com/Emyrk/chronicle/golang/wowlogs/diagnostics"
 	"github.com/Emyrk/chronicle/golang/wowlogs/lines"
 	"github.com/Emyrk/chronicle/golang/wowlogs/merge"
 	"github.com/stretchr/testify/require"
@@ -23,9 +26,9 @@ func TestMerge(t *testing.T) {
 
 	err := m.MergeLogs(
 		t.Context(),
+		&out,
 		strings.NewReader(formattedLog),
 		strings.NewReader(rawLog),
-		&out,
 	)
 	require.NoError(t, err)
 
@@ -54,6 +57,197 @@ func TestMerge(t *testing.T) {
 	require.Equal(t, len(fl)+len(rl), lc)
 }
 
+func TestMergeMany(t *testing.T) {
+	t.Parallel()
+
+	// A second client recorded part of the same raid
+	secondLog := `11/18 07:20:40.000  ZONE_INFO: 18.11.25 07:20:40&hillsbrad foothills&0
+11/18 07:20:50.000  CAST: 0x00000000000F5F4B(Irontooth) casts Battle Shout(11551)(Rank 6).
+11/20 22:08:10.000  CAST: 0x00000000000F5F4B(Irontooth) casts Bloodrage(2687).`
+
+	logger := testutil.Logger(t)
+	m := merge.NewMerger(logger)
+
+	liner, scan, err := m.LineScanner(t.Context(),
+		strings.NewReader(formattedLog),
+		strings.NewReader(rawLog),
+		strings.NewReader(secondLog),
+		strings.NewReader(""),
+	)
+	require.NoError(t, err)
+	require.NotNil(t, liner)
+
+	var contents []string
+	lastTs := time.Time{}
+	for {
+		ts, content, err := scan()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		require.False(t, ts.Before(lastTs), "out of order timestamps: %v before %v", ts, lastTs)
+		lastTs = ts
+		contents = append(contents, content)
+	}
+
+	require.Len(t, contents, len(strings.Split(formattedLog, "\n"))+len(strings.Split(rawLog, "\n"))+3)
+	require.Contains(t, contents[0], "ZONE_INFO")
+	require.Contains(t, contents[len(contents)-1], "Bloodrage")
+	// Lines with the same timestamp come from the raw log first
+	require.Contains(t, contents[3], "0x00000000000EB167(Unknown) casts LOGINEFFECT")
+	require.Contains(t, contents[4], "Unknown casts LOGINEFFECT")
+}
+
+func TestMergeMalformed(t *testing.T) {
+	t.Parallel()
+
+	broken := `11/18 07:20:43.000  CAST: Mooshuggah casts Skinning(8618) on Gray Bear.
+not a log line
+11/18 07:20:44.000  CAST: Mooshuggah casts Flurry(16257)(Rank 1) on Mooshuggah.
+13/45 07:20:45.000  CAST: Mooshuggah casts Flurry(16257)(Rank 1) on Mooshuggah.`
+
+	diag := diagnostics.New()
+	m := merge.NewMerger(testutil.Logger(t), merge.WithDiagnostics(diag))
+
+	var out bytes.Buffer
+	err := m.MergeLogs(t.Context(), &out, strings.NewReader(broken))
+	require.NoError(t, err)
+
+	require.Equal(t, 2, strings.Count(out.String(), "\n"))
+	require.Equal(t, 2, diag.Malformed.Count)
+	require.Equal(t, []string{
+		"not a log line",
+		"13/45 07:20:45.000  CAST: Mooshuggah casts Flurry(16257)(Rank 1) on Mooshuggah.",
+	}, diag.Malformed.Samples)
+}
+
+func TestMergeReorder(t *testing.T) {
+	t.Parallel()
+
+	// A lag spike wrote the second and third lines late
+	lagged := `11/18 07:20:40.000  CAST: Mooshuggah casts Skinning(8618) on Gray Bear.
+11/18 07:20:43.000  CAST: Mooshuggah casts Flame Shock(8052)(Rank 2) on Gray Bear.
+11/18 07:20:41.000  CAST: Mooshuggah casts Flurry(16257)(Rank 1) on Mooshuggah.
+11/18 07:20:42.000  CAST: Mooshuggah casts Lightning Strike(51387)(Rank 1) on Gray Bear.
+11/18 07:20:50.000  CAST: Irontooth begins to cast Hearthstone(8690).
+11/18 07:20:55.000  CAST: Irontooth casts Hearthstone(8690).
+11/18 07:20:44.000  CAST: Irontooth casts Bloodrage(2687).`
+
+	scanAll := func(t *testing.T, r *merge.Reorder) []time.Time {
+		t.Helper()
+
+		m := merge.NewMerger(testutil.Logger(t), merge.WithReorder(r))
+		_, scan, err := m.LineScanner(t.Context(), strings.NewReader(lagged))
+		require.NoError(t, err)
+
+		var stamps []time.Time
+		for {
+			ts, _, err := scan()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			stamps = append(stamps, ts)
+		}
+		return stamps
+	}
+
+	t.Run("InWindow", func(t *testing.T) {
+		t.Parallel()
+
+		r := merge.NewReorder(10 * time.Second)
+		stamps := scanAll(t, r)
+		require.Equal(t, 3, r.Reordered)
+		require.Len(t, stamps, 7)
+		for i := 1; i < len(stamps); i++ {
+			require.False(t, stamps[i].Before(stamps[i-1]), "out of order timestamps: %v before %v", stamps[i], stamps[i-1])
+		}
+	})
+
+	t.Run("BeyondWindow", func(t *testing.T) {
+		t.Parallel()
+
+		// The last line is 11 seconds late, which is too late to move
+		r := merge.NewReorder(3 * time.Second)
+		stamps := scanAll(t, r)
+		require.Equal(t, 2, r.Reordered)
+
+		var secs []int
+		for _, ts := range stamps {
+			secs = append(secs, ts.Second())
+		}
+		require.Equal(t, []int{40, 41, 42, 43, 50, 44, 55}, secs)
+	})
+}
+
+func TestMergeDedupe(t *testing.T) {
+	t.Parallel()
+
+	scanAll := func(t *testing.T, d *merge.Dedupe, readers ...io.Reader) []string {
+		t.Helper()
+
+		m := merge.NewMerger(testutil.Logger(t), merge.WithDedupe(d))
+		_, scan, err := m.LineScanner(t.Context(), readers...)
+		require.NoError(t, err)
+
+		var contents []string
+		lastTs := time.Time{}
+		for {
+			ts, content, err := scan()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			require.False(t, ts.Before(lastTs), "out of order timestamps: %v before %v", ts, lastTs)
+			lastTs = ts
+			contents = append(contents, content)
+		}
+		return contents
+	}
+
+	t.Run("Casts", func(t *testing.T) {
+		t.Parallel()
+
+		d := merge.NewDedupe(merge.DefaultDedupeTolerance)
+		contents := scanAll(t, d, strings.NewReader(formattedLog), strings.NewReader(rawLog))
+
+		// Every formatted cast on 11/18 has a raw pair
+		require.Equal(t, 12, d.Collapsed)
+		// The 11/20 casts have GUIDs in both logs
+		require.Equal(t, 8, d.Unmatched)
+		require.Len(t, contents, len(strings.Split(formattedLog, "\n"))+len(strings.Split(rawLog, "\n"))-12)
+		for _, content := range contents {
+			require.NotContains(t, content, "CAST: Mooshuggah", "formatted cast was kept")
+		}
+	})
+
+	t.Run("Tolerance", func(t *testing.T) {
+		t.Parallel()
+
+		formatted := `11/18 07:21:03.000  Mooshuggah hits Gray Bear for 50.
+11/18 07:21:04.000  Irontooth hits Gray Bear for 20.
+11/18 07:21:09.000  Mooshuggah hits Gray Bear for 70.`
+		raw := `11/18 07:21:02.500  UNIT_INFO: 18.11.25 07:21:02&0x00000000000F5F4B&1&Irontooth&1&0x0000000000000000
+11/18 07:21:03.200  0x00000000000E8AB6(Mooshuggah) hits 0xF13000092F00408E(Gray Bear) for 50.
+11/18 07:21:04.100  0x00000000000F5F4B hits 0xF13000092F00408E(Gray Bear) for 20.
+11/18 07:21:10.000  0x00000000000E8AB6(Mooshuggah) hits 0xF13000092F00408E(Gray Bear) for 70.`
+
+		d := merge.NewDedupe(500 * time.Millisecond)
+		contents := scanAll(t, d, strings.NewReader(formatted), strings.NewReader(raw))
+
+		// The last hit is a second apart, so both lines are kept
+		require.Equal(t, 2, d.Collapsed)
+		require.Equal(t, 1, d.Unmatched)
+		require.Equal(t, []string{
+			"UNIT_INFO: 18.11.25 07:21:02&0x00000000000F5F4B&1&Irontooth&1&0x0000000000000000",
+			"0x00000000000E8AB6(Mooshuggah) hits 0xF13000092F00408E(Gray Bear) for 50.",
+			"0x00000000000F5F4B hits 0xF13000092F00408E(Gray Bear) for 20.",
+			"Mooshuggah hits Gray Bear for 70.",
+			"0x00000000000E8AB6(Mooshuggah) hits 0xF13000092F00408E(Gray Bear) for 70.",
+		}, contents)
+	})
+}
+
 const (
 	formattedLog = `11/18 07:20:42.699  COMBATANT_GUID: 18.11.25 07:20:42&Maldrissa&0x00000000000EB167
 11/18 07:20:42.699  COMBATANT_INFO: 18.11.25 07:20:42&Maldrissa&WARLOCK&Orc&3&Chotuk&Exalted with Doordash&Uber Eats&5&nil&nil&nil&nil&6266:0:96:0&nil&6568:0:237:0&4915:0:0:0&nil&nil&nil&nil&nil&nil&4695:0:0:0&4925:0:0:0&nil&11287:0:0:0&5976:0:0:0&0000000000000000000}000000000000000000}0505001100000000
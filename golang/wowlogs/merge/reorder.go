@@ -0,0 +1,106 @@
+package merge
+
+import (
+	"container/heap"
+	"errors"
+	"io"
+	"time"
+)
+
+// DefaultReorderWindow is how far back a line can be and still be put in
+// order. Client lag spikes and clock skew between log files are usually a
+// few seconds at most.
+const DefaultReorderWindow = 5 * time.Second
+
+// Reorder sorts lines that are slightly out of order. Lines are held back
+// until a line more than the window later has been read, so any line that is
+// at most the window behind is put in place. Lines that are further behind
+// are passed on as is, out of order.
+type Reorder struct {
+	// Window is how far behind a line can be.
+	Window time.Duration
+
+	// Reordered is the number of lines that were moved before a later line.
+	Reordered int
+
+	pending reorderHeap
+	// newest is the latest timestamp read.
+	newest time.Time
+	// emitted is the timestamp of the last line returned.
+	emitted time.Time
+	seq     int
+}
+
+type reorderLine struct {
+	ts      time.Time
+	content string
+	// seq keeps lines with the same timestamp in the order they were read.
+	seq int
+}
+
+type reorderHeap []reorderLine
+
+func (h reorderHeap) Len() int { return len(h) }
+func (h reorderHeap) Less(i, j int) bool {
+	if h[i].ts.Equal(h[j].ts) {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].ts.Before(h[j].ts)
+}
+func (h reorderHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *reorderHeap) Push(x any)   { *h = append(*h, x.(reorderLine)) }
+func (h *reorderHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+func NewReorder(window time.Duration) *Reorder {
+	return &Reorder{
+		Window: window,
+	}
+}
+
+// WithReorder sorts the merged lines that are out of order by at most the
+// window. The count is kept on the Reorder.
+func WithReorder(r *Reorder) Option {
+	return func(m *Merger) {
+		m.reorder = r
+	}
+}
+
+func (r *Reorder) scan(next Scan) Scan {
+	done := false
+	return func() (time.Time, string, error) {
+		for {
+			if len(r.pending) > 0 && (done || r.newest.Sub(r.pending[0].ts) > r.Window) {
+				line := heap.Pop(&r.pending).(reorderLine)
+				r.emitted = line.ts
+				return line.ts, line.content, nil
+			}
+			if done {
+				return time.Time{}, "", io.EOF
+			}
+
+			ts, content, err := next()
+			if errors.Is(err, io.EOF) {
+				done = true
+				continue
+			}
+			if err != nil {
+				return time.Time{}, "", err
+			}
+
+			if ts.Before(r.newest) && !ts.Before(r.emitted) {
+				r.Reordered++
+			}
+			if ts.After(r.newest) {
+				r.newest = ts
+			}
+			heap.Push(&r.pending, reorderLine{ts: ts, content: content, seq: r.seq})
+			r.seq++
+		}
+	}
+}
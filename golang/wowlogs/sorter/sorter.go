@@ -2,19 +2,42 @@ package sorter
 
 import (
 	"bufio"
+	"container/heap"
 	"context"
+	"fmt"
 	"io"
 	"log/slog"
+	"os"
 	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Emyrk/chronicle/golang/wowlogs/diagnostics"
 	"github.com/Emyrk/chronicle/golang/wowlogs/lines"
 )
 
+// DefaultMemoryBudget is how many bytes of lines are sorted in memory before
+// they are spilled to a temp file.
+const DefaultMemoryBudget = 256 << 20
+
+// lineOverhead is the memory used by a line besides the content.
+const lineOverhead = 64
+
+// maxLineSize is the longest line a run file can have.
+const maxLineSize = 1 << 20
+
+// maxMergeRuns is the most run files that are open at once. More runs are
+// merged in batches first.
+const maxMergeRuns = 16
+
 type SortSummary struct {
 	Earliest time.Time
 	Latest   time.Time
 	Total    int
+	// Diagnostics has the lines that were dropped because the timestamp is
+	// malformed.
+	Diagnostics *diagnostics.Report
 }
 
 type logLine struct {
@@ -22,11 +45,49 @@ type logLine struct {
 	Content string
 }
 
-func SortLogs(ctx context.Context, logger *slog.Logger, input io.Reader, output io.Writer) (SortSummary, error) {
-	sum := SortSummary{}
+type Option func(s *sorter)
+
+// WithMemoryBudget sets how many bytes of lines are sorted in memory. Larger
+// logs are sorted in runs that are written to temp files, and merged. 0 or
+// less keeps the whole log in memory.
+func WithMemoryBudget(bytes int) Option {
+	return func(s *sorter) {
+		s.budget = bytes
+	}
+}
+
+// WithTempDir sets where the sorted runs are written. Defaults to the
+// system temp dir.
+func WithTempDir(dir string) Option {
+	return func(s *sorter) {
+		s.tempDir = dir
+	}
+}
+
+type sorter struct {
+	budget  int
+	tempDir string
+	// runs are the sorted run files left to merge, in the order they were
+	// read.
+	runs []string
+	// created is every run file, to remove them when done.
+	created []string
+}
+
+func SortLogs(ctx context.Context, logger *slog.Logger, input io.Reader, output io.Writer, opts ...Option) (SortSummary, error) {
+	s := &sorter{
+		budget: DefaultMemoryBudget,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	defer s.cleanup()
+
+	sum := SortSummary{Diagnostics: diagnostics.New()}
 	buffer := make([]logLine, 0)
+	size := 0
 	liner := lines.NewLiner()
-	sc := bufio.NewScanner(input)
+	sc := bufio.NewScanner(liner.PeekYear(input))
 	for sc.Scan() {
 		if ctx.Err() != nil {
 			return sum, ctx.Err()
@@ -36,12 +97,14 @@ func SortLogs(ctx context.Context, logger *slog.Logger, input io.Reader, output
 		ts, content, err := liner.Line(txt)
 		if err != nil {
 			logger.Warn("skipping failed line", slog.String("line", txt), slog.String("error", err.Error()))
+			sum.Diagnostics.AddMalformed(txt)
 			continue
 		}
 		buffer = append(buffer, logLine{
 			Date:    ts,
 			Content: content,
 		})
+		size += len(content) + lineOverhead
 
 		if ts.Before(sum.Earliest) || sum.Earliest.IsZero() {
 			sum.Earliest = ts
@@ -51,23 +114,243 @@ func SortLogs(ctx context.Context, logger *slog.Logger, input io.Reader, output
 			sum.Latest = ts
 		}
 		sum.Total++
+
+		if s.budget > 0 && size >= s.budget {
+			if err := s.spill(buffer); err != nil {
+				return sum, err
+			}
+			buffer = buffer[:0]
+			size = 0
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return sum, fmt.Errorf("read input: %w", err)
+	}
+
+	sortLines(buffer)
+	if len(s.runs) == 0 {
+		for _, line := range buffer {
+			if ctx.Err() != nil {
+				return sum, ctx.Err()
+			}
+			if err := writeLine(output, liner, line); err != nil {
+				return sum, err
+			}
+		}
+		return sum, nil
 	}
 
-	slices.SortFunc(buffer, func(a, b logLine) int {
-		return int(a.Date.UnixMilli() - b.Date.UnixMilli())
+	// Merge the runs in batches, so only so many files are open at once.
+	for len(s.runs) > maxMergeRuns {
+		if err := s.mergeBatch(ctx); err != nil {
+			return sum, err
+		}
+	}
+
+	logger.Debug("merging sorted runs", slog.Int("runs", len(s.runs)))
+	w := bufio.NewWriter(output)
+	err := s.merge(ctx, s.runs, buffer, func(line logLine) error {
+		return writeLine(w, liner, line)
 	})
+	if err != nil {
+		return sum, err
+	}
+	return sum, w.Flush()
+}
 
-	for _, line := range buffer {
-		if ctx.Err() != nil {
-			return sum, ctx.Err()
+func sortLines(buffer []logLine) {
+	slices.SortStableFunc(buffer, func(a, b logLine) int {
+		return a.Date.Compare(b.Date)
+	})
+}
+
+func writeLine(output io.Writer, liner *lines.Liner, line logLine) error {
+	_, err := output.Write([]byte(liner.FmtLine(line.Date, line.Content)))
+	if err != nil {
+		return err
+	}
+	_, _ = output.Write([]byte("\n"))
+	return nil
+}
+
+// writeRunLine writes a line of a run file. The run keeps the full timestamp,
+// as the log format has no year.
+func writeRunLine(w *bufio.Writer, line logLine) {
+	_, _ = w.WriteString(strconv.FormatInt(line.Date.UnixNano(), 10))
+	_ = w.WriteByte(' ')
+	_, _ = w.WriteString(line.Content)
+	_ = w.WriteByte('\n')
+}
+
+// createRun creates a new run file, and writes the lines with write.
+func (s *sorter) createRun(write func(w *bufio.Writer) error) (string, error) {
+	f, err := os.CreateTemp(s.tempDir, "chronicle_sort_run_*")
+	if err != nil {
+		return "", fmt.Errorf("create run file: %w", err)
+	}
+	s.created = append(s.created, f.Name())
+
+	w := bufio.NewWriter(f)
+	if err := write(w); err != nil {
+		_ = f.Close()
+		return "", err
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return "", fmt.Errorf("write run file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("close run file: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// spill sorts the lines and writes them to a new run file.
+func (s *sorter) spill(buffer []logLine) error {
+	sortLines(buffer)
+
+	path, err := s.createRun(func(w *bufio.Writer) error {
+		for _, line := range buffer {
+			writeRunLine(w, line)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, path)
+	return nil
+}
+
+// mergeBatch merges the oldest runs into one. The merged run goes first, so
+// lines with the same timestamp keep the order they were read in.
+func (s *sorter) mergeBatch(ctx context.Context) error {
+	batch := s.runs[:maxMergeRuns]
+	path, err := s.createRun(func(w *bufio.Writer) error {
+		return s.merge(ctx, batch, nil, func(line logLine) error {
+			writeRunLine(w, line)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, run := range batch {
+		_ = os.Remove(run)
+	}
+	s.runs = append([]string{path}, s.runs[maxMergeRuns:]...)
+	return nil
+}
+
+func (s *sorter) cleanup() {
+	for _, run := range s.created {
+		_ = os.Remove(run)
+	}
+}
 
-		_, err := output.Write([]byte(liner.FmtLine(line.Date, line.Content)))
+// merge does a k-way merge of the run files and the lines still in memory.
+// Lines with the same timestamp keep the order they were read in.
+func (s *sorter) merge(ctx context.Context, runs []string, buffer []logLine, write func(logLine) error) error {
+	var pending runHeap
+	for index, path := range runs {
+		f, err := os.Open(path)
 		if err != nil {
-			return sum, err
+			return fmt.Errorf("open run file: %w", err)
+		}
+		defer f.Close()
+
+		sc := bufio.NewScanner(f)
+		sc.Buffer(nil, maxLineSize)
+		r := &run{index: index, scanner: sc}
+		if err := r.next(); err != nil {
+			return err
+		}
+		if !r.done {
+			pending = append(pending, r)
+		}
+	}
+	if len(buffer) > 0 {
+		pending = append(pending, &run{index: len(runs), memory: buffer, line: buffer[0]})
+	}
+	heap.Init(&pending)
+
+	for len(pending) > 0 {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		r := pending[0]
+		if err := write(r.line); err != nil {
+			return err
+		}
+		if err := r.next(); err != nil {
+			return err
+		}
+		if r.done {
+			heap.Pop(&pending)
+		} else {
+			heap.Fix(&pending, 0)
+		}
+	}
+	return nil
+}
+
+// run is a sorted run of lines, either in a file or still in memory.
+type run struct {
+	index   int
+	scanner *bufio.Scanner
+	memory  []logLine
+	line    logLine
+	done    bool
+}
+
+func (r *run) next() error {
+	if r.scanner == nil {
+		r.memory = r.memory[1:]
+		if len(r.memory) == 0 {
+			r.done = true
+			return nil
+		}
+		r.line = r.memory[0]
+		return nil
+	}
+
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return fmt.Errorf("read run file: %w", err)
 		}
-		_, _ = output.Write([]byte("\n"))
+		r.done = true
+		return nil
 	}
 
-	return sum, nil
+	stamp, content, ok := strings.Cut(r.scanner.Text(), " ")
+	if !ok {
+		return fmt.Errorf("invalid run line: %q", r.scanner.Text())
+	}
+	nanos, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid run timestamp %q: %w", stamp, err)
+	}
+	r.line = logLine{Date: time.Unix(0, nanos).UTC(), Content: content}
+	return nil
+}
+
+type runHeap []*run
+
+func (h runHeap) Len() int { return len(h) }
+func (h runHeap) Less(i, j int) bool {
+	if h[i].line.Date.Equal(h[j].line.Date) {
+		return h[i].index < h[j].index
+	}
+	return h[i].line.Date.Before(h[j].line.Date)
+}
+func (h runHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x any)   { *h = append(*h, x.(*run)) }
+func (h *runHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
 }
@@ -0,0 +1,67 @@
+package sorter_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Emyrk/chronicle/golang/internal/testutil"
+	"github.com/Emyrk/chronicle/golang/wowlogs/sorter"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortLogs(t *testing.T) {
+	t.Parallel()
+
+	// Every other line is written a few seconds late, and the lines with the
+	// same timestamp must keep their order.
+	var input strings.Builder
+	input.WriteString("18.11.25 not a line\n")
+	for i := range 200 {
+		sec := (i * 7) % 60
+		if i%2 == 1 {
+			sec = (sec + 3) % 60
+		}
+		fmt.Fprintf(&input, "11/18 07:%02d:%02d.000  Mooshuggah hits Gray Bear for %d.\n", 20+i/60, sec, i)
+		fmt.Fprintf(&input, "11/18 07:%02d:%02d.000  Gray Bear hits Mooshuggah for %d.\n", 20+i/60, sec, i)
+	}
+
+	sortLogs := func(t *testing.T, opts ...sorter.Option) (sorter.SortSummary, string) {
+		t.Helper()
+
+		var out bytes.Buffer
+		sum, err := sorter.SortLogs(t.Context(), testutil.Logger(t), strings.NewReader(input.String()), &out, opts...)
+		require.NoError(t, err)
+		return sum, out.String()
+	}
+
+	memSum, memOut := sortLogs(t)
+	require.Equal(t, 400, memSum.Total)
+	require.Equal(t, 1, memSum.Diagnostics.Malformed.Count)
+
+	sorted := strings.Split(strings.TrimSuffix(memOut, "\n"), "\n")
+	require.Len(t, sorted, 400)
+	require.True(t, strings.HasPrefix(sorted[0], "11/18 07:20:00.000"), sorted[0])
+	for i := 1; i < len(sorted); i++ {
+		require.LessOrEqual(t, sorted[i-1][:18], sorted[i][:18])
+	}
+
+	t.Run("External", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		// A few lines per run, so there are about 40 runs and they are merged
+		// in batches
+		sum, out := sortLogs(t, sorter.WithMemoryBudget(1024), sorter.WithTempDir(dir))
+		require.Equal(t, memSum.Total, sum.Total)
+		require.Equal(t, memSum.Earliest, sum.Earliest)
+		require.Equal(t, memSum.Latest, sum.Latest)
+		require.Equal(t, memOut, out)
+
+		runs, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, runs, "run files are removed")
+	})
+}
@@ -2,9 +2,11 @@ package loot
 
 import (
   "fmt"
+  "strconv"
   "strings"
   "time"
 
+  "github.com/Emyrk/chronicle/golang/wowlogs/regexs"
   "github.com/Emyrk/chronicle/golang/wowlogs/types"
 )
 
@@ -16,30 +18,122 @@ func IsLoot(content string) (string, bool) {
   return types.Is(PrefixLoot, content)
 }
 
+// Quality is the item quality, taken from the colour of the item link.
+type Quality int
+
+const (
+  QualityUnknown Quality = iota - 1
+  QualityPoor
+  QualityCommon
+  QualityUncommon
+  QualityRare
+  QualityEpic
+  QualityLegendary
+  QualityArtifact
+)
+
+var qualityColours = map[string]Quality{
+  "ff9d9d9d": QualityPoor,
+  "ffffffff": QualityCommon,
+  "ff1eff00": QualityUncommon,
+  "ff0070dd": QualityRare,
+  "ffa335ee": QualityEpic,
+  "ffff8000": QualityLegendary,
+  "ffe6cc80": QualityArtifact,
+}
+
+// QualityFromColour returns the item quality for the item link colour.
+func QualityFromColour(colour string) Quality {
+  q, ok := qualityColours[strings.ToLower(colour)]
+  if !ok {
+    return QualityUnknown
+  }
+  return q
+}
+
+func (q Quality) String() string {
+  switch q {
+  case QualityPoor:
+    return "Poor"
+  case QualityCommon:
+    return "Common"
+  case QualityUncommon:
+    return "Uncommon"
+  case QualityRare:
+    return "Rare"
+  case QualityEpic:
+    return "Epic"
+  case QualityLegendary:
+    return "Legendary"
+  case QualityArtifact:
+    return "Artifact"
+  default:
+    return "Unknown"
+  }
+}
+
+// Loot is a single item received by a unit.
+//
+// Examples:
+// LOOT: 20.11.25 14:37:08&0x000000000001C7AC receives loot: |cffffffff|Hitem:18144:0:0:0|h[Human Bone Chip]|h|r.
+// LOOT: 20.11.25 22:41:13&Doyd receives loot: |cffa335ee|Hitem:16915:0:0:0|h[Netherwind Pants]|h|rx1.
 type Loot struct {
-  Seen time.Time
-  // TODO: Parse the rest
+  Seen     time.Time
+  Receiver types.Unit
+  // Colour is the raw colour of the item link, like "ffa335ee".
+  Colour  string
+  Quality Quality
+  ItemID  uint32
+  // EnchantID, SuffixID and UniqueID are the rest of the item link.
+  // SuffixID is the random "of the Bear" style suffix.
+  EnchantID uint32
+  SuffixID  uint32
+  UniqueID  uint32
+  ItemName  string
+  // Count defaults to 1 if the line has no count.
+  Count int32
 }
 
 func ParseLootInfo(content string) (Loot, error) {
-  trimmed, ok := IsLoot(content)
-  if !ok {
+  if _, ok := IsLoot(content); !ok {
     return Loot{}, fmt.Errorf("not a LOOT message")
   }
 
-  parts := strings.Split(trimmed, "&")
-
-  if len(parts) < 2 {
-    return Loot{}, fmt.Errorf("insufficient arguments in LOOT message, got %d, want at least 2", len(parts))
+  matched, ok := types.FromRegex(regexs.ReLoot).Match(content)
+  if !ok {
+    return Loot{}, fmt.Errorf("LOOT failed: %s", content)
   }
 
-  ts, _ := parts[0], parts[1]
+  ts := matched.String()
   seen, err := time.Parse(types.AddonDateFormat, ts)
   if err != nil {
     return Loot{}, fmt.Errorf("invalid date format %q: %w", ts, err)
   }
 
-  return Loot{
-    Seen: seen,
-  }, nil
+  l := Loot{
+    Seen:      seen,
+    Receiver:  matched.Unit(),
+    Colour:    matched.String(),
+    ItemID:    matched.Uint32(),
+    EnchantID: matched.Uint32(),
+    SuffixID:  matched.Uint32(),
+    UniqueID:  matched.Uint32(),
+    ItemName:  matched.String(),
+    Count:     1,
+  }
+  l.Quality = QualityFromColour(l.Colour)
+
+  if err := matched.Error(); err != nil {
+    return Loot{}, fmt.Errorf("LOOT: %w", err)
+  }
+
+  if count := matched.String(); count != "" {
+    c, err := strconv.ParseInt(count, 10, 32)
+    if err != nil {
+      return Loot{}, fmt.Errorf("invalid count %q: %w", count, err)
+    }
+    l.Count = int32(c)
+  }
+
+  return l, nil
 }
@@ -0,0 +1,88 @@
+package loot_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/loot"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLootInfo(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		content string
+		expErr  bool
+
+		exp loot.Loot
+	}{
+		{
+			name:    "NoCount",
+			content: "LOOT: 20.11.25 14:37:08&0x000000000001C7AC receives loot: |cffffffff|Hitem:18144:0:0:0|h[Human Bone Chip]|h|r.",
+			exp: loot.Loot{
+				Seen:     time.Date(2025, 11, 20, 14, 37, 8, 0, time.UTC),
+				Receiver: types.Unit{Gid: guid.GUID(0x000000000001C7AC)},
+				Colour:   "ffffffff",
+				Quality:  loot.QualityCommon,
+				ItemID:   18144,
+				ItemName: "Human Bone Chip",
+				Count:    1,
+			},
+		},
+		{
+			name:    "EpicWithCount",
+			content: "LOOT: 20.11.25 22:41:13&Doyd receives loot: |cffa335ee|Hitem:16915:0:0:0|h[Netherwind Pants]|h|rx1.",
+			exp: loot.Loot{
+				Seen:     time.Date(2025, 11, 20, 22, 41, 13, 0, time.UTC),
+				Receiver: types.Unit{Name: "Doyd"},
+				Colour:   "ffa335ee",
+				Quality:  loot.QualityEpic,
+				ItemID:   16915,
+				ItemName: "Netherwind Pants",
+				Count:    1,
+			},
+		},
+		{
+			name:    "SuffixAndStack",
+			content: "LOOT: 20.11.25 22:41:13&0x00000000000EBF01(Aramarah) receives loot: |cff1eff00|Hitem:15210:0:1024:0|h[Raider's Shortsword of the Monkey]|h|rx3.",
+			exp: loot.Loot{
+				Seen:     time.Date(2025, 11, 20, 22, 41, 13, 0, time.UTC),
+				Receiver: types.Unit{Name: "Aramarah", Gid: guid.GUID(0x00000000000EBF01)},
+				Colour:   "ff1eff00",
+				Quality:  loot.QualityUncommon,
+				ItemID:   15210,
+				SuffixID: 1024,
+				ItemName: "Raider's Shortsword of the Monkey",
+				Count:    3,
+			},
+		},
+		{
+			name:    "NotLoot",
+			content: "ZONE_INFO: 20.11.25 14:37:08&Molten Core&409",
+			expErr:  true,
+		},
+		{
+			name:    "BadLink",
+			content: "LOOT: 20.11.25 14:37:08&Doyd receives loot: [Human Bone Chip].",
+			expErr:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := loot.ParseLootInfo(tc.content)
+			if tc.expErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, got)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package consumables
+
+// Category groups consumables for policy checks.
+type Category string
+
+const (
+	CategoryFlask       Category = "flask"
+	CategoryElixir      Category = "elixir"
+	CategoryJuju        Category = "juju"
+	CategoryPotion      Category = "potion"
+	CategoryHealthstone Category = "healthstone"
+	CategoryRune        Category = "rune"
+	CategorySapper      Category = "sapper"
+	CategoryFood        Category = "food"
+	CategoryWeapon      Category = "weapon"
+)
+
+// Consumable is an item that is used by a player. Consumables are detected
+// by the spell the item casts, or the aura it applies.
+type Consumable struct {
+	// Item is the name of the item.
+	Item string
+	// SpellID is the spell cast by the item, from CAST lines.
+	SpellID int
+	// SpellName is the spell or aura name in the logs. Aura lines do not
+	// include a spell ID, so they are matched by name.
+	SpellName string
+	Category  Category
+}
+
+// Table is every known consumable. Add new items here.
+var Table = []Consumable{
+	// Flasks
+	{Item: "Flask of the Titans", SpellID: 17626, SpellName: "Flask of the Titans", Category: CategoryFlask},
+	{Item: "Flask of Distilled Wisdom", SpellID: 17627, SpellName: "Distilled Wisdom", Category: CategoryFlask},
+	{Item: "Flask of Supreme Power", SpellID: 17628, SpellName: "Supreme Power", Category: CategoryFlask},
+	{Item: "Flask of Chromatic Resistance", SpellID: 17629, SpellName: "Chromatic Resistance", Category: CategoryFlask},
+
+	// Elixirs
+	{Item: "Elixir of the Mongoose", SpellID: 17538, SpellName: "Elixir of the Mongoose", Category: CategoryElixir},
+	{Item: "Elixir of Giants", SpellID: 11405, SpellName: "Elixir of the Giants", Category: CategoryElixir},
+	{Item: "Greater Arcane Elixir", SpellID: 17539, SpellName: "Greater Arcane Elixir", Category: CategoryElixir},
+	{Item: "Elixir of Shadow Power", SpellID: 11474, SpellName: "Shadow Power", Category: CategoryElixir},
+	{Item: "Elixir of Greater Firepower", SpellID: 26276, SpellName: "Greater Firepower", Category: CategoryElixir},
+	{Item: "Elixir of Frost Power", SpellID: 21920, SpellName: "Frost Power", Category: CategoryElixir},
+	{Item: "Elixir of Greater Agility", SpellID: 11334, SpellName: "Greater Agility", Category: CategoryElixir},
+	{Item: "Elixir of Greater Intellect", SpellID: 11396, SpellName: "Greater Intellect", Category: CategoryElixir},
+	{Item: "Elixir of Superior Defense", SpellID: 11348, SpellName: "Greater Armor", Category: CategoryElixir},
+	{Item: "Elixir of Fortitude", SpellID: 3593, SpellName: "Health II", Category: CategoryElixir},
+	{Item: "Major Troll's Blood Potion", SpellID: 24361, SpellName: "Regeneration", Category: CategoryElixir},
+	{Item: "Mageblood Potion", SpellID: 24363, SpellName: "Mana Regeneration", Category: CategoryElixir},
+	{Item: "Gift of Arthas", SpellID: 11371, SpellName: "Gift of Arthas", Category: CategoryElixir},
+	{Item: "Rumsey Rum Black Label", SpellID: 25804, SpellName: "Rumsey Rum Black Label", Category: CategoryElixir},
+
+	// Juju
+	{Item: "Juju Power", SpellID: 16323, SpellName: "Juju Power", Category: CategoryJuju},
+	{Item: "Juju Might", SpellID: 16329, SpellName: "Juju Might", Category: CategoryJuju},
+	{Item: "Juju Flurry", SpellID: 16322, SpellName: "Juju Flurry", Category: CategoryJuju},
+	{Item: "Juju Guile", SpellID: 16327, SpellName: "Juju Guile", Category: CategoryJuju},
+	{Item: "Juju Escape", SpellID: 16321, SpellName: "Juju Escape", Category: CategoryJuju},
+	{Item: "Juju Ember", SpellID: 16326, SpellName: "Juju Ember", Category: CategoryJuju},
+	{Item: "Juju Chill", SpellID: 16325, SpellName: "Juju Chill", Category: CategoryJuju},
+
+	// Potions
+	{Item: "Major Mana Potion", SpellID: 17531, SpellName: "Restore Mana", Category: CategoryPotion},
+	{Item: "Major Healing Potion", SpellID: 17534, SpellName: "Healing Potion", Category: CategoryPotion},
+	{Item: "Mighty Rage Potion", SpellID: 17528, SpellName: "Mighty Rage", Category: CategoryPotion},
+	{Item: "Great Rage Potion", SpellID: 6613, SpellName: "Great Rage", Category: CategoryPotion},
+	{Item: "Rage Potion", SpellID: 6612, SpellName: "Rage", Category: CategoryPotion},
+	{Item: "Thistle Tea", SpellID: 9512, SpellName: "Restore Energy", Category: CategoryPotion},
+	{Item: "Free Action Potion", SpellID: 6615, SpellName: "Free Action", Category: CategoryPotion},
+	{Item: "Limited Invulnerability Potion", SpellID: 3169, SpellName: "Invulnerability", Category: CategoryPotion},
+	{Item: "Restorative Potion", SpellID: 11359, SpellName: "Restoration", Category: CategoryPotion},
+	{Item: "Greater Stoneshield Potion", SpellID: 17540, SpellName: "Greater Stoneshield", Category: CategoryPotion},
+	{Item: "Greater Fire Protection Potion", SpellID: 17543, SpellName: "Fire Protection", Category: CategoryPotion},
+	{Item: "Greater Frost Protection Potion", SpellID: 17544, SpellName: "Frost Protection", Category: CategoryPotion},
+	{Item: "Greater Nature Protection Potion", SpellID: 17546, SpellName: "Nature Protection", Category: CategoryPotion},
+	{Item: "Greater Shadow Protection Potion", SpellID: 17548, SpellName: "Shadow Protection", Category: CategoryPotion},
+	{Item: "Greater Arcane Protection Potion", SpellID: 17549, SpellName: "Arcane Protection", Category: CategoryPotion},
+
+	// Healthstones
+	{Item: "Major Healthstone", SpellID: 23476, SpellName: "Major Healthstone", Category: CategoryHealthstone},
+	{Item: "Major Healthstone", SpellID: 23477, SpellName: "Major Healthstone", Category: CategoryHealthstone},
+
+	// Runes
+	{Item: "Dark Rune", SpellID: 27869, SpellName: "Dark Rune", Category: CategoryRune},
+	{Item: "Demonic Rune", SpellID: 16666, SpellName: "Demonic Rune", Category: CategoryRune},
+
+	// Sappers
+	{Item: "Goblin Sapper Charge", SpellID: 13241, SpellName: "Goblin Sapper Charge", Category: CategorySapper},
+
+	// Food
+	{Item: "Dirge's Kickin' Chimaerok Chops", SpellID: 25661, SpellName: "Increased Stamina", Category: CategoryFood},
+	{Item: "Grilled Squid", SpellID: 18192, SpellName: "Increased Agility", Category: CategoryFood},
+	{Item: "Runn Tum Tuber Surprise", SpellID: 22730, SpellName: "Increased Intellect", Category: CategoryFood},
+	{Item: "Nightfin Soup", SpellID: 18194, SpellName: "Mana Regeneration", Category: CategoryFood},
+	{Item: "Smoked Desert Dumplings", SpellID: 24800, SpellName: "Well Fed", Category: CategoryFood},
+
+	// Weapon oils and stones
+	{Item: "Brilliant Wizard Oil", SpellID: 25122, SpellName: "Brilliant Wizard Oil", Category: CategoryWeapon},
+	{Item: "Brilliant Mana Oil", SpellID: 25123, SpellName: "Brilliant Mana Oil", Category: CategoryWeapon},
+	{Item: "Dense Sharpening Stone", SpellID: 16138, SpellName: "Sharpen Blade V", Category: CategoryWeapon},
+	{Item: "Elemental Sharpening Stone", SpellID: 22756, SpellName: "Sharpen Weapon - Critical", Category: CategoryWeapon},
+	{Item: "Dense Weightstone", SpellID: 16622, SpellName: "Weighted +8", Category: CategoryWeapon},
+}
+
+var byID = func() map[int]Consumable {
+	idx := make(map[int]Consumable)
+	for _, c := range Table {
+		idx[c.SpellID] = c
+	}
+	return idx
+}()
+
+var byName = func() map[string][]Consumable {
+	idx := make(map[string][]Consumable)
+	for _, c := range Table {
+		idx[c.SpellName] = append(idx[c.SpellName], c)
+	}
+	return idx
+}()
+
+// ByID returns the consumable that casts the spell.
+func ByID(spellID int) (Consumable, bool) {
+	c, ok := byID[spellID]
+	return c, ok
+}
+
+// ByName returns the consumable with the spell or aura name. Some items share
+// an aura name, like Mageblood Potion and Nightfin Soup for "Mana
+// Regeneration". The first item in the Table wins, so prefer ByID when the
+// spell ID is known, and AllByName to tell them apart.
+func ByName(spellName string) (Consumable, bool) {
+	all := byName[spellName]
+	if len(all) == 0 {
+		return Consumable{}, false
+	}
+	return all[0], true
+}
+
+// AllByName returns every consumable with the spell or aura name, in Table
+// order.
+func AllByName(spellName string) []Consumable {
+	return byName[spellName]
+}
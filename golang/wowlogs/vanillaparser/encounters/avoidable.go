@@ -0,0 +1,29 @@
+package encounters
+
+// AnyEncounter is the key for avoidable damage that applies to every fight,
+// including trash.
+const AnyEncounter = ""
+
+// AvoidableDamage is the damage players can avoid, keyed by the encounter name
+// then listing the spell names. It is the default catalog, and can be replaced
+// per parse.
+var AvoidableDamage = map[string][]string{
+	AnyEncounter: {
+		// Naxxramas trash
+		"Poison Charge",
+	},
+	"Magmadar":                {"Lava Bomb"},
+	"Ragnaros":                {"Lava Burst"},
+	"Broodlord Lashlayer":     {"Blast Wave"},
+	"Vaelastrasz the Corrupt": {"Fire Nova"},
+	"Onyxia":                  {"Flame Breath", "Eruption"},
+	"Patchwerk":               {"Hateful Strike"},
+	"The Four Horsemen":       {"Void Zone", "Meteor"},
+	"Heigan the Unclean":      {"Eruption"},
+	"Grobbulus":               {"Poison Cloud"},
+	"Sapphiron":               {"Chill"},
+	"Kel'Thuzad":              {"Shadow Fissure"},
+	"C'Thun":                  {"Eye Beam", "Dark Glare"},
+	"Princess Huhuran":        {"Noxious Poison"},
+	"Ouro":                    {"Sweep", "Sand Blast"},
+}
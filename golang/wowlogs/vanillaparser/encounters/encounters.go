@@ -0,0 +1,152 @@
+package encounters
+
+import "github.com/Emyrk/chronicle/golang/wowlogs/guid"
+
+const (
+	MoltenCore       = "Molten Core"
+	Onyxia           = "Onyxia's Lair"
+	BlackwingLair    = "Blackwing Lair"
+	ZulGurub         = "Zul'Gurub"
+	RuinsOfAhnQiraj  = "Ruins of Ahn'Qiraj"
+	TempleOfAhnQiraj = "Temple of Ahn'Qiraj"
+	Naxxramas        = "Naxxramas"
+)
+
+// Encounter is a boss fight, keyed by the creature entries involved.
+type Encounter struct {
+	Name string
+	Raid string
+	// Bosses are the creature entries that must all die for the encounter to
+	// be a kill. Multi boss encounters like the Twin Emperors list every boss.
+	Bosses []uint32
+	// Related are creature entries that identify the encounter, but do not
+	// need to die. Like the Eye of C'Thun.
+	Related []uint32
+	// KillEveryUnit requires every unit of a boss entry seen in the fight to
+	// die, like the Flamewaker adds of Majordomo. By default a boss entry is
+	// dead once any of its units dies, as Skeram's images share his entry
+	// and despawn without dying.
+	KillEveryUnit bool
+}
+
+// Catalog is every known vanilla raid encounter.
+var Catalog = []Encounter{
+	// Molten Core
+	{Name: "Lucifron", Raid: MoltenCore, Bosses: []uint32{12118}},
+	{Name: "Magmadar", Raid: MoltenCore, Bosses: []uint32{11982}},
+	{Name: "Gehennas", Raid: MoltenCore, Bosses: []uint32{12259}},
+	{Name: "Garr", Raid: MoltenCore, Bosses: []uint32{12057}},
+	{Name: "Baron Geddon", Raid: MoltenCore, Bosses: []uint32{12056}},
+	{Name: "Shazzrah", Raid: MoltenCore, Bosses: []uint32{12264}},
+	{Name: "Sulfuron Harbinger", Raid: MoltenCore, Bosses: []uint32{12098}},
+	{Name: "Golemagg the Incinerator", Raid: MoltenCore, Bosses: []uint32{11988}},
+	// Majordomo does not die, the encounter is won by killing his adds.
+	{Name: "Majordomo Executus", Raid: MoltenCore, Bosses: []uint32{11663, 11664}, Related: []uint32{12018}, KillEveryUnit: true},
+	{Name: "Ragnaros", Raid: MoltenCore, Bosses: []uint32{11502}},
+
+	// Onyxia's Lair
+	{Name: "Onyxia", Raid: Onyxia, Bosses: []uint32{10184}},
+
+	// Blackwing Lair
+	{Name: "Razorgore the Untamed", Raid: BlackwingLair, Bosses: []uint32{12435}},
+	{Name: "Vaelastrasz the Corrupt", Raid: BlackwingLair, Bosses: []uint32{13020}},
+	{Name: "Broodlord Lashlayer", Raid: BlackwingLair, Bosses: []uint32{12017}},
+	{Name: "Firemaw", Raid: BlackwingLair, Bosses: []uint32{11983}},
+	{Name: "Ebonroc", Raid: BlackwingLair, Bosses: []uint32{14601}},
+	{Name: "Flamegor", Raid: BlackwingLair, Bosses: []uint32{11981}},
+	{Name: "Chromaggus", Raid: BlackwingLair, Bosses: []uint32{14020}},
+	{Name: "Nefarian", Raid: BlackwingLair, Bosses: []uint32{11583}, Related: []uint32{10162}},
+
+	// Zul'Gurub
+	{Name: "High Priestess Jeklik", Raid: ZulGurub, Bosses: []uint32{14517}},
+	{Name: "High Priest Venoxis", Raid: ZulGurub, Bosses: []uint32{14507}},
+	{Name: "High Priestess Mar'li", Raid: ZulGurub, Bosses: []uint32{14510}},
+	{Name: "Bloodlord Mandokir", Raid: ZulGurub, Bosses: []uint32{11382}},
+	{Name: "Gri'lek", Raid: ZulGurub, Bosses: []uint32{15082}},
+	{Name: "Hazza'rah", Raid: ZulGurub, Bosses: []uint32{15083}},
+	{Name: "Renataki", Raid: ZulGurub, Bosses: []uint32{15084}},
+	{Name: "Wushoolay", Raid: ZulGurub, Bosses: []uint32{15085}},
+	{Name: "Gahz'ranka", Raid: ZulGurub, Bosses: []uint32{15114}},
+	{Name: "High Priest Thekal", Raid: ZulGurub, Bosses: []uint32{14509}},
+	{Name: "High Priestess Arlokk", Raid: ZulGurub, Bosses: []uint32{14515}},
+	{Name: "Jin'do the Hexxer", Raid: ZulGurub, Bosses: []uint32{11380}},
+	{Name: "Hakkar", Raid: ZulGurub, Bosses: []uint32{14834}},
+
+	// Ruins of Ahn'Qiraj
+	{Name: "Kurinnaxx", Raid: RuinsOfAhnQiraj, Bosses: []uint32{15348}},
+	{Name: "General Rajaxx", Raid: RuinsOfAhnQiraj, Bosses: []uint32{15341}},
+	{Name: "Moam", Raid: RuinsOfAhnQiraj, Bosses: []uint32{15340}},
+	{Name: "Buru the Gorger", Raid: RuinsOfAhnQiraj, Bosses: []uint32{15370}},
+	{Name: "Ayamiss the Hunter", Raid: RuinsOfAhnQiraj, Bosses: []uint32{15369}},
+	{Name: "Ossirian the Unscarred", Raid: RuinsOfAhnQiraj, Bosses: []uint32{15339}},
+
+	// Temple of Ahn'Qiraj
+	{Name: "The Prophet Skeram", Raid: TempleOfAhnQiraj, Bosses: []uint32{15263}},
+	{Name: "Silithid Royalty", Raid: TempleOfAhnQiraj, Bosses: []uint32{15511, 15543, 15544}},
+	{Name: "Battleguard Sartura", Raid: TempleOfAhnQiraj, Bosses: []uint32{15516}},
+	{Name: "Fankriss the Unyielding", Raid: TempleOfAhnQiraj, Bosses: []uint32{15510}},
+	{Name: "Viscidus", Raid: TempleOfAhnQiraj, Bosses: []uint32{15299}},
+	{Name: "Princess Huhuran", Raid: TempleOfAhnQiraj, Bosses: []uint32{15509}},
+	{Name: "Twin Emperors", Raid: TempleOfAhnQiraj, Bosses: []uint32{15275, 15276}},
+	{Name: "Ouro", Raid: TempleOfAhnQiraj, Bosses: []uint32{15517}},
+	{Name: "C'Thun", Raid: TempleOfAhnQiraj, Bosses: []uint32{15727}, Related: []uint32{15589}},
+
+	// Naxxramas
+	{Name: "Anub'Rekhan", Raid: Naxxramas, Bosses: []uint32{15956}},
+	{Name: "Grand Widow Faerlina", Raid: Naxxramas, Bosses: []uint32{15953}},
+	{Name: "Maexxna", Raid: Naxxramas, Bosses: []uint32{15952}},
+	{Name: "Noth the Plaguebringer", Raid: Naxxramas, Bosses: []uint32{15954}},
+	{Name: "Heigan the Unclean", Raid: Naxxramas, Bosses: []uint32{15936}},
+	{Name: "Loatheb", Raid: Naxxramas, Bosses: []uint32{16011}},
+	{Name: "Instructor Razuvious", Raid: Naxxramas, Bosses: []uint32{16061}},
+	{Name: "Gothik the Harvester", Raid: Naxxramas, Bosses: []uint32{16060}},
+	{Name: "The Four Horsemen", Raid: Naxxramas, Bosses: []uint32{16062, 16063, 16064, 16065}},
+	{Name: "Patchwerk", Raid: Naxxramas, Bosses: []uint32{16028}},
+	{Name: "Grobbulus", Raid: Naxxramas, Bosses: []uint32{15931}},
+	{Name: "Gluth", Raid: Naxxramas, Bosses: []uint32{15932}},
+	{Name: "Thaddius", Raid: Naxxramas, Bosses: []uint32{15928}, Related: []uint32{15929, 15930}},
+	{Name: "Sapphiron", Raid: Naxxramas, Bosses: []uint32{15989}},
+	{Name: "Kel'Thuzad", Raid: Naxxramas, Bosses: []uint32{15990}},
+}
+
+var byEntry = func() map[uint32]int {
+	idx := make(map[uint32]int)
+	for i, enc := range Catalog {
+		for _, entry := range enc.Bosses {
+			idx[entry] = i
+		}
+		for _, entry := range enc.Related {
+			idx[entry] = i
+		}
+	}
+	return idx
+}()
+
+// ByEntry returns the encounter the creature entry belongs to.
+func ByEntry(entry uint32) (Encounter, bool) {
+	i, ok := byEntry[entry]
+	if !ok {
+		return Encounter{}, false
+	}
+	return Catalog[i], true
+}
+
+// Lookup returns the encounter the unit belongs to.
+func Lookup(id guid.GUID) (Encounter, bool) {
+	entry, ok := id.GetEntry()
+	if !ok {
+		return Encounter{}, false
+	}
+	return ByEntry(entry)
+}
+
+// IsBoss returns true if the creature entry must die for the encounter to be
+// a kill.
+func (e Encounter) IsBoss(entry uint32) bool {
+	for _, boss := range e.Bosses {
+		if boss == entry {
+			return true
+		}
+	}
+	return false
+}
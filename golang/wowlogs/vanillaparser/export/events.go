@@ -0,0 +1,51 @@
+package export
+
+import (
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/state"
+)
+
+// EventLog keeps the combat events of a log, to be exported as rows once the
+// log is parsed. Unit names and fights are only known at the end.
+type EventLog struct {
+	messages []messages.Message
+}
+
+func NewEventLog() *EventLog {
+	return &EventLog{}
+}
+
+// Record keeps the message if it is an exported event type.
+func (l *EventLog) Record(m messages.Message) {
+	switch m.(type) {
+	case messages.Damage, messages.FallDamage, messages.Heal, messages.Aura, messages.Slain,
+		messages.Cast, messages.ResourceChange, messages.Interrupt, messages.Create:
+		l.messages = append(l.messages, m)
+	}
+}
+
+// Events returns the recorded events, oldest first, with the unit names and
+// fights from the final state.
+func (l *EventLog) Events(s *state.State) []Event {
+	e := exporter{s: s}
+	fights := s.Fights.Fights
+
+	events := make([]Event, 0, len(l.messages))
+	var idx int
+	for _, m := range l.messages {
+		event, ok := e.event(m)
+		if !ok {
+			continue
+		}
+
+		// Messages are in order, so the fight index only moves forward.
+		for idx < len(fights) && fights[idx].IsDone() && fights[idx].End.Date().Before(event.At) {
+			idx++
+		}
+		if idx < len(fights) && fights[idx].IsStarted() && !event.At.Before(fights[idx].Start.Date()) {
+			event.Fight = idx + 1
+		}
+		events = append(events, event)
+	}
+	return events
+}
@@ -0,0 +1,373 @@
+package export
+
+import (
+	"cmp"
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/state"
+)
+
+// FromState builds the export model from the parsed state.
+func FromState(s *state.State) *Report {
+	e := exporter{s: s}
+	r := &Report{
+		SchemaVersion: SchemaVersion,
+		Me:            UnitRef{GUID: s.Me.Gid.String(), Name: s.Me.Name},
+		Units:         e.units(),
+		Fights:        []Fight{},
+		Loot:          []Loot{},
+		Consumables:   []Consumables{},
+	}
+	for i, fight := range s.Fights.Fights {
+		if !fight.IsStarted() {
+			continue
+		}
+		r.Fights = append(r.Fights, e.fight(i, fight))
+	}
+	for _, drop := range s.Loot.Drops {
+		r.Loot = append(r.Loot, e.loot(drop))
+	}
+	for _, instance := range s.Consumables.ByInstance() {
+		r.Consumables = append(r.Consumables, e.consumables(instance))
+	}
+	return r
+}
+
+// WriteJSON writes the report as indented JSON.
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+type exporter struct {
+	s *state.State
+}
+
+func (e exporter) ref(id guid.GUID) UnitRef {
+	ref := UnitRef{GUID: id.String()}
+	if info, ok := e.s.Units.Get(id); ok {
+		ref.Name = info.Name
+	}
+	return ref
+}
+
+func (e exporter) units() []Unit {
+	units := make([]Unit, 0, len(e.s.Units.Info))
+	for _, id := range slices.Sorted(maps.Keys(e.s.Units.Info)) {
+		info := e.s.Units.Info[id]
+		unit := Unit{
+			UnitRef:  UnitRef{GUID: id.String(), Name: info.Name},
+			IsPlayer: info.IsPlayer,
+			Friendly: info.CanCooperate,
+		}
+		if info.Owner != nil {
+			unit.Owner = info.Owner.String()
+		}
+		if player, ok := e.s.Units.Players[id]; ok {
+			unit.Class = player.HeroClass.String()
+			unit.Race = player.Race.String()
+			if player.Guild != nil {
+				unit.Guild = player.Guild.Name
+			}
+		}
+		units = append(units, unit)
+	}
+	return units
+}
+
+func (e exporter) fight(i int, f *state.Fight) Fight {
+	out := Fight{
+		Number:       i + 1,
+		Zone:         f.CurrentZone.Name,
+		InstanceID:   f.CurrentZone.InstanceID,
+		Outcome:      string(f.Outcome),
+		EndReason:    string(f.EndReason),
+		Start:        f.Start.Date(),
+		DurationMS:   f.Duration().Milliseconds(),
+		Done:         f.IsDone(),
+		Participants: []string{},
+		Deaths:       []Death{},
+		Interrupts:   []Interrupt{},
+	}
+	if f.IsDone() {
+		out.End = f.End.Date()
+	}
+	if f.Encounter != nil {
+		out.Encounter = f.Encounter.Name
+		out.Raid = f.Encounter.Raid
+	}
+	for _, id := range slices.Sorted(maps.Keys(f.Lives)) {
+		out.Participants = append(out.Participants, id.String())
+	}
+
+	out.Damage = e.meter(f.DamageByOwner(), f.Duration())
+	out.Healing = e.meter(f.HealingByOwner(), f.Duration())
+
+	for _, death := range f.Deaths {
+		out.Deaths = append(out.Deaths, e.death(death))
+	}
+	for _, kick := range f.Interrupts.Interrupts {
+		out.Interrupts = append(out.Interrupts, Interrupt{
+			At:        kick.At,
+			Caster:    e.ref(kick.Caster),
+			Ability:   kick.Ability,
+			Target:    e.ref(kick.Target),
+			SpellName: kick.SpellName,
+		})
+	}
+	return out
+}
+
+func (e exporter) meter(m *state.Meter, dur time.Duration) Meter {
+	entries := func(ranking []state.UnitAmount, byUnit map[guid.GUID]*state.MeterEntry, done bool) []MeterEntry {
+		out := make([]MeterEntry, 0, len(ranking))
+		for _, row := range ranking {
+			entry := byUnit[row.Unit]
+			spells := make([]SpellAmount, 0, len(entry.BySpell))
+			for name, spell := range entry.BySpell {
+				spells = append(spells, SpellAmount{
+					SpellName: name,
+					Total:     spell.Total,
+					Count:     spell.Count,
+					Hits:      spell.Hits,
+					Crits:     spell.Crits,
+				})
+			}
+			slices.SortFunc(spells, func(a, b SpellAmount) int {
+				if c := cmp.Compare(b.Total, a.Total); c != 0 {
+					return c
+				}
+				return cmp.Compare(a.SpellName, b.SpellName)
+			})
+			units := make([]UnitAmount, 0, len(entry.ByUnit))
+			for id, total := range entry.ByUnit {
+				units = append(units, UnitAmount{Unit: e.ref(id), Total: total})
+			}
+			slices.SortFunc(units, func(a, b UnitAmount) int {
+				if c := cmp.Compare(b.Total, a.Total); c != 0 {
+					return c
+				}
+				return cmp.Compare(a.Unit.GUID, b.Unit.GUID)
+			})
+			schools := make([]SchoolAmount, 0, len(entry.BySchool))
+			for school, total := range entry.BySchool {
+				schools = append(schools, SchoolAmount{School: schoolName(school), Total: total})
+			}
+			slices.SortFunc(schools, func(a, b SchoolAmount) int {
+				if c := cmp.Compare(b.Total, a.Total); c != 0 {
+					return c
+				}
+				return cmp.Compare(a.School, b.School)
+			})
+			out = append(out, MeterEntry{
+				Unit:    e.ref(row.Unit),
+				Total:   entry.Total,
+				Count:   entry.Count,
+				Crits:   entry.Crits,
+				Spells:  spells,
+				Units:   units,
+				Schools: schools,
+			})
+			if done {
+				out[len(out)-1].PerSecond = m.PerSecond(row.Unit, dur)
+			}
+		}
+		return out
+	}
+
+	return Meter{
+		Total: m.Total,
+		Done:  entries(m.DoneRanking(), m.Done, true),
+		Taken: entries(m.TakenRanking(), m.Taken, false),
+	}
+}
+
+func (e exporter) death(d state.DeathRecap) Death {
+	out := Death{
+		At:     d.Slain.Date(),
+		Victim: e.ref(d.Victim),
+		Events: []Event{},
+	}
+	if d.Killer != nil {
+		killer := e.ref(*d.Killer)
+		out.Killer = &killer
+	}
+	if d.KillingBlow != nil {
+		if blow, ok := e.event(d.KillingBlow); ok {
+			out.KillingBlow = &blow
+		}
+	}
+	for _, m := range d.Events {
+		if event, ok := e.event(m); ok {
+			out.Events = append(out.Events, event)
+		}
+	}
+	return out
+}
+
+// event converts the message, returning false for messages that are not
+// part of the export model.
+func (e exporter) event(m messages.Message) (Event, bool) {
+	caster := func(id guid.GUID) *UnitRef {
+		ref := e.ref(id)
+		return &ref
+	}
+
+	switch typed := m.(type) {
+	case messages.Damage:
+		event := Event{
+			Type:    EventTypeDamage,
+			At:      typed.Date(),
+			Caster:  caster(typed.Caster),
+			Target:  e.ref(typed.Target),
+			Amount:  typed.Amount,
+			School:  schoolName(typed.School),
+			HitType: uint32(typed.HitType),
+			Crit:    typed.HitType.Has(types.HitTypeCrit),
+		}
+		if typed.SpellName != nil {
+			event.SpellName = *typed.SpellName
+		}
+		return event, true
+	case messages.FallDamage:
+		return Event{
+			Type:   EventTypeFallDamage,
+			At:     typed.Date(),
+			Target: e.ref(typed.Target),
+			Amount: typed.Amount,
+		}, true
+	case messages.Heal:
+		return Event{
+			Type:      EventTypeHeal,
+			At:        typed.Date(),
+			Caster:    caster(typed.Caster),
+			Target:    e.ref(typed.Target),
+			SpellName: typed.SpellName,
+			Amount:    typed.Amount,
+			HitType:   uint32(typed.HitType),
+			Crit:      typed.HitType.Has(types.HitTypeCrit),
+		}, true
+	case messages.Aura:
+		return Event{
+			Type:        EventTypeAura,
+			At:          typed.Date(),
+			Target:      e.ref(typed.Target),
+			SpellName:   typed.SpellName,
+			Amount:      typed.Amount,
+			Application: strings.ToLower(typed.Application.String()),
+			Harmful:     typed.Harmful,
+		}, true
+	case messages.Slain:
+		event := Event{
+			Type:   EventTypeDeath,
+			At:     typed.Date(),
+			Target: e.ref(typed.Victim),
+		}
+		if typed.Killer != nil {
+			event.Caster = caster(*typed.Killer)
+		}
+		return event, true
+	case messages.Cast:
+		event := Event{
+			Type:      EventTypeCast,
+			At:        typed.Date(),
+			Caster:    caster(typed.Caster.Gid),
+			SpellName: typed.Spell.Name,
+			SpellID:   typed.Spell.ID,
+			Action:    typed.Action.String(),
+		}
+		if typed.Target != nil {
+			event.Target = e.ref(typed.Target.Gid)
+		}
+		return event, true
+	case messages.ResourceChange:
+		event := Event{
+			Type:     EventTypeResource,
+			At:       typed.Date(),
+			Target:   e.ref(typed.Target),
+			Amount:   typed.Amount,
+			Action:   typed.Direction,
+			Resource: strings.ToLower(typed.Resource.String()),
+		}
+		if typed.Caster != nil {
+			event.Caster = caster(*typed.Caster)
+		}
+		if typed.SpellName != nil {
+			event.SpellName = *typed.SpellName
+		}
+		return event, true
+	case messages.Interrupt:
+		return Event{
+			Type:      EventTypeInterrupt,
+			At:        typed.Date(),
+			Caster:    caster(typed.Caster),
+			Target:    e.ref(typed.Target),
+			SpellName: typed.SpellName,
+		}, true
+	case messages.Create:
+		return Event{
+			Type:      EventTypeCreate,
+			At:        typed.Date(),
+			Caster:    caster(typed.Caster),
+			SpellName: typed.Created,
+		}, true
+	}
+	return Event{}, false
+}
+
+func (e exporter) loot(drop state.LootDrop) Loot {
+	out := Loot{
+		At:       drop.Date(),
+		Receiver: e.ref(drop.Receiver),
+		ItemID:   drop.ItemID,
+		ItemName: drop.ItemName,
+		Quality:  strings.ToLower(drop.Quality.String()),
+		Count:    drop.Count,
+	}
+	if out.Receiver.Name == "" {
+		out.Receiver.Name = drop.Loot.Loot.Receiver.Name
+	}
+	if drop.FightIndex >= 0 {
+		out.FightNumber = drop.FightIndex + 1
+	}
+	if drop.Encounter != nil {
+		out.Encounter = drop.Encounter.Name
+	}
+	return out
+}
+
+func (e exporter) consumables(instance state.InstanceConsumables) Consumables {
+	out := Consumables{
+		Zone:       instance.Zone.Name,
+		InstanceID: instance.Zone.InstanceID,
+		Player:     e.ref(instance.Player),
+		Items:      make([]ItemCount, 0, len(instance.Items)),
+	}
+	for _, item := range slices.Sorted(maps.Keys(instance.Items)) {
+		out.Items = append(out.Items, ItemCount{Item: item, Count: instance.Items[item]})
+	}
+	return out
+}
+
+var schoolNames = map[types.School]string{
+	types.PhysicalSchool: "physical",
+	types.HolySchool:     "holy",
+	types.FireSchool:     "fire",
+	types.NatureSchool:   "nature",
+	types.FrostSchool:    "frost",
+	types.ShadowSchool:   "shadow",
+	types.ArcaneSchool:   "arcane",
+}
+
+func schoolName(school types.School) string {
+	return schoolNames[school]
+}
@@ -0,0 +1,155 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/internal/ptr"
+	"github.com/Emyrk/chronicle/golang/internal/testutil"
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/unitinfo"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/zone"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/export"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/state"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	priest  guid.GUID = 0x000000000001C7AC
+	warrior guid.GUID = 0x0000000000062A1B
+	boss    guid.GUID = 0xF130000950003FB5
+)
+
+var start = time.Date(2025, 10, 17, 21, 0, 0, 0, time.UTC)
+
+func at(sec float64) messages.MessageBase {
+	return messages.Base(start.Add(time.Duration(sec * float64(time.Second))))
+}
+
+func TestFromState(t *testing.T) {
+	t.Parallel()
+
+	s, _ := parsedState(t)
+
+	report := export.FromState(s)
+	require.Equal(t, export.SchemaVersion, report.SchemaVersion)
+	require.Equal(t, export.UnitRef{GUID: "0x000000000001C7AC", Name: "Doyd"}, report.Me)
+	require.Len(t, report.Units, 3)
+
+	require.Len(t, report.Fights, 1)
+	fight := report.Fights[0]
+	require.Equal(t, 1, fight.Number)
+	require.True(t, fight.Done)
+	require.Equal(t, int64(3000), fight.DurationMS)
+	require.Equal(t, int64(1900), fight.Damage.Total)
+	require.Equal(t, "Gray Bear", fight.Damage.Done[0].Unit.Name)
+	require.Equal(t, "Doyd", fight.Damage.Done[1].Unit.Name)
+	require.Equal(t, []export.SpellAmount{{SpellName: "Smite", Total: 600, Count: 2, Hits: 2, Crits: 1}}, fight.Damage.Done[1].Spells)
+	require.InDelta(t, 200.0, fight.Damage.Done[1].PerSecond, 0.001)
+	require.Equal(t, []export.UnitAmount{{Unit: export.UnitRef{GUID: "0xF130000950003FB5", Name: "Gray Bear"}, Total: 600}}, fight.Damage.Done[1].Units)
+	require.Equal(t, []export.SchoolAmount{{School: "holy", Total: 600}}, fight.Damage.Done[1].Schools)
+	require.Equal(t, []export.UnitAmount{
+		{Unit: export.UnitRef{GUID: "0x000000000001C7AC", Name: "Doyd"}, Total: 600},
+		{Unit: export.UnitRef{GUID: "0x0000000000062A1B", Name: "Tankman"}, Total: 400},
+	}, fight.Damage.Taken[0].Units)
+	require.Equal(t, []export.SchoolAmount{{School: "holy", Total: 600}, {School: "physical", Total: 400}}, fight.Damage.Taken[0].Schools)
+
+	require.Len(t, fight.Deaths, 2)
+	death := fight.Deaths[0]
+	require.Equal(t, "Tankman", death.Victim.Name)
+	require.Equal(t, "Gray Bear", death.Killer.Name)
+	require.NotNil(t, death.KillingBlow)
+	require.Equal(t, export.EventTypeDamage, death.KillingBlow.Type)
+	require.Equal(t, "Maul", death.KillingBlow.SpellName)
+	require.Equal(t, "physical", death.KillingBlow.School)
+	require.True(t, death.KillingBlow.Crit)
+
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteJSON(&buf, report))
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.EqualValues(t, export.SchemaVersion, decoded["schema_version"])
+	fights := decoded["fights"].([]any)
+	deaths := fights[0].(map[string]any)["deaths"].([]any)
+	blow := deaths[0].(map[string]any)["killing_blow"].(map[string]any)
+	require.Equal(t, "damage", blow["type"])
+	require.Equal(t, "0x0000000000062A1B", blow["target"].(map[string]any)["guid"])
+}
+
+func TestFromStateConsumables(t *testing.T) {
+	t.Parallel()
+
+	s := state.NewState(testutil.Logger(t), types.Unit{Name: "Doyd", Gid: priest})
+	for _, m := range []messages.Message{
+		messages.Unit{MessageBase: at(0), Info: unitinfo.Info{Guid: warrior, Name: "Tankman", IsPlayer: true, CanCooperate: true}},
+		messages.Zone{MessageBase: at(0), Zone: zone.Zone{Name: "Molten Core", InstanceID: 7}},
+		messages.Aura{MessageBase: at(1), Target: warrior, SpellName: "Flask of the Titans", Application: types.AuraApplicationGains},
+		messages.Aura{MessageBase: at(2), Target: warrior, SpellName: "Mighty Rage", Application: types.AuraApplicationGains},
+		messages.Aura{MessageBase: at(100), Target: warrior, SpellName: "Mighty Rage", Application: types.AuraApplicationGains},
+	} {
+		require.NoError(t, s.Process(m))
+	}
+
+	report := export.FromState(s)
+	require.Equal(t, []export.Consumables{{
+		Zone:       "Molten Core",
+		InstanceID: 7,
+		Player:     export.UnitRef{GUID: "0x0000000000062A1B", Name: "Tankman"},
+		Items: []export.ItemCount{
+			{Item: "Flask of the Titans", Count: 1},
+			{Item: "Mighty Rage Potion", Count: 2},
+		},
+	}}, report.Consumables)
+}
+
+// parsedState is a single fight where the warrior dies, and the priest kills
+// the boss. The events are recorded in the returned log.
+func parsedState(t *testing.T) (*state.State, *export.EventLog) {
+	t.Helper()
+
+	events := export.NewEventLog()
+	s := state.NewState(testutil.Logger(t), types.Unit{Name: "Doyd", Gid: priest})
+	for _, m := range []messages.Message{
+		messages.Unit{MessageBase: at(0), Info: unitinfo.Info{Guid: priest, Name: "Doyd", IsPlayer: true, CanCooperate: true}},
+		messages.Unit{MessageBase: at(0), Info: unitinfo.Info{Guid: warrior, Name: "Tankman", IsPlayer: true, CanCooperate: true}},
+		messages.Unit{MessageBase: at(0), Info: unitinfo.Info{Guid: boss, Name: "Gray Bear"}},
+		messages.Damage{MessageBase: at(1), Caster: warrior, Target: boss, SpellName: ptr.Ref("Heroic Strike"), Amount: 400, HitType: types.HitTypeHit, School: types.PhysicalSchool},
+		messages.Damage{MessageBase: at(2), Caster: priest, Target: boss, SpellName: ptr.Ref("Smite"), Amount: 100, HitType: types.HitTypeHit, School: types.HolySchool},
+		messages.Damage{MessageBase: at(3), Caster: boss, Target: warrior, SpellName: ptr.Ref("Maul"), Amount: 900, HitType: types.HitTypeCrit, School: types.PhysicalSchool},
+		messages.Slain{MessageBase: at(3), Victim: warrior, Killer: ptr.Ref(boss)},
+		messages.Damage{MessageBase: at(4), Caster: priest, Target: boss, SpellName: ptr.Ref("Smite"), Amount: 500, HitType: types.HitTypeCrit, School: types.HolySchool},
+		messages.Slain{MessageBase: at(4), Victim: boss, Killer: ptr.Ref(priest)},
+	} {
+		events.Record(m)
+		require.NoError(t, s.Process(m))
+	}
+
+	return s, events
+}
+
+func TestEventLog(t *testing.T) {
+	t.Parallel()
+
+	s, log := parsedState(t)
+	events := log.Events(s)
+	require.Len(t, events, 6)
+
+	types := make([]export.EventType, 0, len(events))
+	for _, event := range events {
+		require.Equal(t, 1, event.Fight)
+		types = append(types, event.Type)
+	}
+	require.Equal(t, []export.EventType{
+		export.EventTypeDamage, export.EventTypeDamage, export.EventTypeDamage,
+		export.EventTypeDeath, export.EventTypeDamage, export.EventTypeDeath,
+	}, types)
+
+	death := events[3]
+	require.Equal(t, "Tankman", death.Target.Name)
+	require.Equal(t, "Gray Bear", death.Caster.Name)
+}
@@ -0,0 +1,199 @@
+package export
+
+import "time"
+
+// SchemaVersion is the version of the export model. It is bumped on any
+// breaking change, like removing or renaming a field. Adding fields does not
+// bump the version.
+const SchemaVersion = 1
+
+// Report is the exported state of a parsed log.
+type Report struct {
+	SchemaVersion int     `json:"schema_version"`
+	Me            UnitRef `json:"me"`
+	Units         []Unit  `json:"units"`
+	Fights        []Fight `json:"fights"`
+	Loot          []Loot  `json:"loot"`
+	// Consumables are counted per player and raid instance, over the whole
+	// log.
+	Consumables []Consumables `json:"consumables"`
+}
+
+// UnitRef is a reference to a unit. GUIDs are hex strings, like
+// "0x0000000000062A1B".
+type UnitRef struct {
+	GUID string `json:"guid"`
+	Name string `json:"name"`
+}
+
+// Unit is everything known about a unit seen in the log.
+type Unit struct {
+	UnitRef
+	IsPlayer bool   `json:"is_player"`
+	Friendly bool   `json:"friendly"`
+	Owner    string `json:"owner,omitempty"`
+	// Class, Race and Guild are only known for players with combatant info.
+	Class string `json:"class,omitempty"`
+	Race  string `json:"race,omitempty"`
+	Guild string `json:"guild,omitempty"`
+}
+
+// Fight is a single completed or in progress fight.
+type Fight struct {
+	// Number is the fight number as printed in the reports, starting at 1.
+	Number     int       `json:"number"`
+	Zone       string    `json:"zone,omitempty"`
+	InstanceID uint32    `json:"instance_id,omitempty"`
+	Encounter  string    `json:"encounter,omitempty"`
+	Raid       string    `json:"raid,omitempty"`
+	Outcome    string    `json:"outcome,omitempty"`
+	EndReason  string    `json:"end_reason,omitempty"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	DurationMS int64     `json:"duration_ms"`
+	Done       bool      `json:"done"`
+
+	Participants []string    `json:"participants"`
+	Damage       Meter       `json:"damage"`
+	Healing      Meter       `json:"healing"`
+	Deaths       []Death     `json:"deaths"`
+	Interrupts   []Interrupt `json:"interrupts"`
+}
+
+// Meter is the damage or healing of a fight. Pets are folded into their
+// owners.
+type Meter struct {
+	Total int64        `json:"total"`
+	Done  []MeterEntry `json:"done"`
+	Taken []MeterEntry `json:"taken"`
+}
+
+// MeterEntry is the breakdown of a single unit, largest first in a meter.
+type MeterEntry struct {
+	Unit      UnitRef       `json:"unit"`
+	Total     int64         `json:"total"`
+	Count     int           `json:"count"`
+	Crits     int           `json:"crits"`
+	PerSecond float64       `json:"per_second"`
+	Spells    []SpellAmount `json:"spells"`
+	// Units are the targets of a done entry, or the casters of a taken entry.
+	Units   []UnitAmount   `json:"units"`
+	Schools []SchoolAmount `json:"schools"`
+}
+
+// UnitAmount is the amount between two units, largest first in an entry.
+type UnitAmount struct {
+	Unit  UnitRef `json:"unit"`
+	Total int64   `json:"total"`
+}
+
+// SchoolAmount is the amount of a single spell school, largest first in an
+// entry.
+type SchoolAmount struct {
+	School string `json:"school"`
+	Total  int64  `json:"total"`
+}
+
+// SpellAmount is the amount of a single spell, largest first in an entry.
+type SpellAmount struct {
+	SpellName string `json:"spell_name"`
+	Total     int64  `json:"total"`
+	Count     int    `json:"count"`
+	Hits      int    `json:"hits"`
+	Crits     int    `json:"crits"`
+}
+
+// Consumables is every consumable a single player used in a single raid
+// instance.
+type Consumables struct {
+	Zone       string      `json:"zone,omitempty"`
+	InstanceID uint32      `json:"instance_id,omitempty"`
+	Player     UnitRef     `json:"player"`
+	Items      []ItemCount `json:"items"`
+}
+
+// ItemCount is the number of uses of a single item, sorted by item name.
+type ItemCount struct {
+	Item  string `json:"item"`
+	Count int    `json:"count"`
+}
+
+// Death is a single unit death with the events leading up to it.
+type Death struct {
+	At     time.Time `json:"at"`
+	Victim UnitRef   `json:"victim"`
+	Killer *UnitRef  `json:"killer,omitempty"`
+	// KillingBlow is the last event that did damage to the victim.
+	KillingBlow *Event  `json:"killing_blow,omitempty"`
+	Events      []Event `json:"events"`
+}
+
+// Interrupt is a single successful interrupt.
+type Interrupt struct {
+	At        time.Time `json:"at"`
+	Caster    UnitRef   `json:"caster"`
+	Ability   string    `json:"ability,omitempty"`
+	Target    UnitRef   `json:"target"`
+	SpellName string    `json:"spell_name"`
+}
+
+// Loot is a single looted item.
+type Loot struct {
+	At       time.Time `json:"at"`
+	Receiver UnitRef   `json:"receiver"`
+	ItemID   uint32    `json:"item_id"`
+	ItemName string    `json:"item_name"`
+	Quality  string    `json:"quality"`
+	Count    int32     `json:"count"`
+	// FightNumber is the boss kill the item was attributed to, 0 if none.
+	FightNumber int    `json:"fight_number,omitempty"`
+	Encounter   string `json:"encounter,omitempty"`
+}
+
+// EventType discriminates the events in death recaps and event rows.
+type EventType string
+
+const (
+	EventTypeDamage     EventType = "damage"
+	EventTypeFallDamage EventType = "fall_damage"
+	EventTypeHeal       EventType = "heal"
+	EventTypeAura       EventType = "aura"
+	// EventTypeDeath has the victim as the target, and the killer as the
+	// caster if known.
+	EventTypeDeath     EventType = "death"
+	EventTypeCast      EventType = "cast"
+	EventTypeResource  EventType = "resource"
+	EventTypeInterrupt EventType = "interrupt"
+	// EventTypeCreate is an item created by the caster, like a healthstone.
+	// The item is the spell name, and there is no target.
+	EventTypeCreate EventType = "create"
+)
+
+// Event is a single combat log event. Type decides which fields are set.
+type Event struct {
+	Type EventType `json:"type"`
+	At   time.Time `json:"at"`
+	// Fight is the number of the fight the event happened in, 0 if it was
+	// outside of a fight. Only set for exported event rows.
+	Fight int `json:"fight,omitempty"`
+	// Caster is not set for fall damage and auras.
+	Caster *UnitRef `json:"caster,omitempty"`
+	// Target is not set for creates, and casts without a target.
+	Target    UnitRef `json:"target"`
+	SpellName string  `json:"spell_name,omitempty"`
+	// SpellID is only known for casts.
+	SpellID int   `json:"spell_id,omitempty"`
+	Amount  int32 `json:"amount,omitempty"`
+	// School is the damage school, like "fire".
+	School string `json:"school,omitempty"`
+	// HitType is the raw hit type bitmask of damage and heals.
+	HitType uint32 `json:"hit_type,omitempty"`
+	Crit    bool   `json:"crit,omitempty"`
+	// Application is set for auras, either "gains", "fades" or "removed".
+	Application string `json:"application,omitempty"`
+	// Action is the cast action, like "begins to cast", or "gains" and
+	// "loses" for resources.
+	Action   string `json:"action,omitempty"`
+	Resource string `json:"resource,omitempty"`
+	Harmful  bool   `json:"harmful,omitempty"`
+}
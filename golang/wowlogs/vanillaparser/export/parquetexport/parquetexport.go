@@ -0,0 +1,24 @@
+// Package parquetexport writes event rows as a Parquet file. It is separate
+// from the export package so the WASM build does not pull in Parquet.
+package parquetexport
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/export"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Write writes the rows as a single Parquet file.
+func Write(w io.Writer, rows []export.Row) error {
+	pw := parquet.NewGenericWriter[export.Row](w)
+	if _, err := pw.Write(rows); err != nil {
+		return fmt.Errorf("write rows: %w", err)
+	}
+	if err := pw.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+	return nil
+}
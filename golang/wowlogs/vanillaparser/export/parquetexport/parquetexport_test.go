@@ -0,0 +1,35 @@
+package parquetexport_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/export"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/export/parquetexport"
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 10, 17, 21, 0, 0, 0, time.UTC)
+	rows := []export.Row{
+		{Timestamp: start, Fight: 1, Type: "damage", SourceName: "Tankman", TargetName: "Patchwerk", SpellName: "Heroic Strike", Amount: 400, School: "physical", HitType: 4, Crit: true},
+		{Timestamp: start.Add(time.Second), Fight: 1, Type: "cast", SourceName: "Doyd", SpellName: "Flash Heal", SpellID: 10917, Action: "begins to cast"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, parquetexport.Write(&buf, rows))
+
+	read, err := parquet.Read[export.Row](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, read, 2)
+	require.Equal(t, rows[0].SpellName, read[0].SpellName)
+	require.Equal(t, rows[0].Amount, read[0].Amount)
+	require.True(t, read[0].Crit)
+	require.True(t, rows[0].Timestamp.Equal(read[0].Timestamp))
+	require.Equal(t, int32(10917), read[1].SpellID)
+	require.Equal(t, "begins to cast", read[1].Action)
+}
@@ -0,0 +1,126 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+)
+
+// Row is a single event flattened into typed columns, for analysis tools like
+// pandas or DuckDB. Columns that do not apply to the event type are empty.
+type Row struct {
+	Timestamp time.Time `parquet:"timestamp"`
+	// Fight is the fight number, 0 if the event was outside of a fight.
+	Fight      int32  `parquet:"fight"`
+	Type       string `parquet:"type"`
+	SourceGUID string `parquet:"source_guid"`
+	SourceName string `parquet:"source_name"`
+	TargetGUID string `parquet:"target_guid"`
+	TargetName string `parquet:"target_name"`
+	SpellName  string `parquet:"spell_name"`
+	SpellID    int32  `parquet:"spell_id"`
+	Amount     int64  `parquet:"amount"`
+	School     string `parquet:"school"`
+	// Action is the cast action, resource direction or aura application.
+	Action   string `parquet:"action"`
+	Resource string `parquet:"resource"`
+	Harmful  bool   `parquet:"harmful"`
+
+	// HitType is the raw bitmask, the flags below are decoded from it.
+	HitType  uint32 `parquet:"hit_type"`
+	Hit      bool   `parquet:"hit"`
+	Crit     bool   `parquet:"crit"`
+	Miss     bool   `parquet:"miss"`
+	Dodge    bool   `parquet:"dodge"`
+	Parry    bool   `parquet:"parry"`
+	Block    bool   `parquet:"block"`
+	Resist   bool   `parquet:"resist"`
+	Absorb   bool   `parquet:"absorb"`
+	Glancing bool   `parquet:"glancing"`
+	Crushing bool   `parquet:"crushing"`
+	Periodic bool   `parquet:"periodic"`
+}
+
+// Rows flattens the events.
+func Rows(events []Event) []Row {
+	rows := make([]Row, 0, len(events))
+	for _, event := range events {
+		hit := types.HitType(event.HitType)
+		row := Row{
+			Timestamp:  event.At,
+			Fight:      int32(event.Fight),
+			Type:       string(event.Type),
+			TargetGUID: event.Target.GUID,
+			TargetName: event.Target.Name,
+			SpellName:  event.SpellName,
+			SpellID:    int32(event.SpellID),
+			Amount:     int64(event.Amount),
+			School:     event.School,
+			Action:     event.Action,
+			Resource:   event.Resource,
+			Harmful:    event.Harmful,
+			HitType:    event.HitType,
+			Hit:        hit.Has(types.HitTypeHit),
+			Crit:       hit.Has(types.HitTypeCrit),
+			Miss:       hit.Has(types.HitTypeMiss),
+			Dodge:      hit.Has(types.HitTypeDodge),
+			Parry:      hit.Has(types.HitTypeParry),
+			Block:      hit.Has(types.HitTypePartialBlock | types.HitTypeFullBlock),
+			Resist:     hit.Has(types.HitTypePartialResist | types.HitTypeFullResist),
+			Absorb:     hit.Has(types.HitTypePartialAbsorb | types.HitTypeFullAbsorb),
+			Glancing:   hit.Has(types.HitTypeGlancing),
+			Crushing:   hit.Has(types.HitTypeCrushing),
+			Periodic:   hit.Has(types.HitTypePeriodic),
+		}
+		if event.Type == EventTypeAura {
+			row.Action = event.Application
+		}
+		if event.Caster != nil {
+			row.SourceGUID = event.Caster.GUID
+			row.SourceName = event.Caster.Name
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+var csvHeader = []string{
+	"timestamp", "fight", "type",
+	"source_guid", "source_name", "target_guid", "target_name",
+	"spell_name", "spell_id", "amount", "school", "action", "resource", "harmful",
+	"hit_type", "hit", "crit", "miss", "dodge", "parry", "block", "resist", "absorb", "glancing", "crushing", "periodic",
+}
+
+// WriteCSV writes the rows with a header. Timestamps are RFC 3339 with
+// milliseconds.
+func WriteCSV(w io.Writer, rows []Row) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	b := strconv.FormatBool
+	for _, row := range rows {
+		record := []string{
+			row.Timestamp.Format("2006-01-02T15:04:05.000Z07:00"),
+			strconv.Itoa(int(row.Fight)),
+			row.Type,
+			row.SourceGUID, row.SourceName, row.TargetGUID, row.TargetName,
+			row.SpellName,
+			strconv.Itoa(int(row.SpellID)),
+			strconv.FormatInt(row.Amount, 10),
+			row.School, row.Action, row.Resource, b(row.Harmful),
+			strconv.FormatUint(uint64(row.HitType), 10),
+			b(row.Hit), b(row.Crit), b(row.Miss), b(row.Dodge), b(row.Parry), b(row.Block),
+			b(row.Resist), b(row.Absorb), b(row.Glancing), b(row.Crushing), b(row.Periodic),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,65 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/castv2"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/export"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRows(t *testing.T) {
+	t.Parallel()
+
+	s, log := parsedState(t)
+	for _, m := range []messages.Message{
+		messages.Cast{MessageBase: at(10), CastV2: castv2.CastV2{Caster: types.Unit{Name: "Doyd", Gid: priest}, Action: types.CastActionsBeginsToCast, Spell: types.Spell{Name: "Flash Heal", ID: 10917}}},
+		messages.ResourceChange{MessageBase: at(11), Target: priest, Amount: 1500, Resource: types.ResourceMana, Direction: "gains"},
+		messages.Interrupt{MessageBase: at(12), Caster: warrior, Target: boss, SpellName: "Fear"},
+		messages.Create{MessageBase: at(13), Caster: priest, Created: "Major Healthstone"},
+		// Not an event
+		messages.Zone{MessageBase: at(14)},
+	} {
+		log.Record(m)
+	}
+
+	rows := export.Rows(log.Events(s))
+	require.Len(t, rows, 10)
+
+	heroicStrike := rows[0]
+	require.Equal(t, "damage", heroicStrike.Type)
+	require.Equal(t, int32(1), heroicStrike.Fight)
+	require.Equal(t, "Tankman", heroicStrike.SourceName)
+	require.Equal(t, "Gray Bear", heroicStrike.TargetName)
+	require.Equal(t, "physical", heroicStrike.School)
+	require.True(t, heroicStrike.Hit)
+	require.False(t, heroicStrike.Crit)
+
+	cast := rows[6]
+	require.Equal(t, "cast", cast.Type)
+	require.Equal(t, int32(0), cast.Fight, "after the fight ended")
+	require.Equal(t, int32(10917), cast.SpellID)
+	require.Equal(t, "begins to cast", cast.Action)
+	require.Empty(t, cast.TargetGUID)
+
+	resource := rows[7]
+	require.Equal(t, "mana", resource.Resource)
+	require.Equal(t, "gains", resource.Action)
+	require.Equal(t, int64(1500), resource.Amount)
+
+	require.Equal(t, "interrupt", rows[8].Type)
+	require.Equal(t, "Major Healthstone", rows[9].SpellName)
+
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteCSV(&buf, rows))
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 11)
+	require.Equal(t, "timestamp", records[0][0])
+	require.Equal(t, "2025-10-17T21:00:01.000Z", records[1][0])
+	require.Equal(t, "Heroic Strike", records[1][7])
+}
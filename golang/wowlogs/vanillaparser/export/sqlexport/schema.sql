@@ -0,0 +1,143 @@
+-- The SQLite export is modelled on the LegacyPlayers schema in
+-- research/legacywow/schema.sql. Vanilla logs have no spell or NPC ids for
+-- most events, so spells are keyed by name and units by guid. Timestamps are
+-- unix milliseconds, like the *_ts columns of the original schema.
+--
+-- Every log written to the database is a row in log_import. Units, spells
+-- and encounters are shared between logs, everything else hangs off the
+-- import it came from.
+
+CREATE TABLE IF NOT EXISTS `log_import` (
+  `id` INTEGER PRIMARY KEY,
+  `schema_version` INTEGER NOT NULL,
+  `me_guid` TEXT NOT NULL,
+  `me_name` TEXT NOT NULL,
+  `imported_ts` INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS `data_encounter` (
+  `id` INTEGER PRIMARY KEY,
+  `name` TEXT NOT NULL UNIQUE,
+  `raid` TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS `data_encounter_npcs` (
+  `encounter_id` INTEGER NOT NULL REFERENCES `data_encounter` (`id`),
+  `npc_id` INTEGER NOT NULL,
+  `requires_death` INTEGER NOT NULL DEFAULT 1,
+  PRIMARY KEY (`encounter_id`, `npc_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `data_spell` (
+  `id` INTEGER PRIMARY KEY,
+  `name` TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS `unit` (
+  `id` INTEGER PRIMARY KEY,
+  `guid` TEXT NOT NULL UNIQUE,
+  `name` TEXT NOT NULL,
+  `is_player` INTEGER NOT NULL,
+  `friendly` INTEGER NOT NULL,
+  `owner_id` INTEGER REFERENCES `unit` (`id`),
+  `hero_class` TEXT,
+  `race` TEXT,
+  `guild` TEXT
+);
+
+CREATE TABLE IF NOT EXISTS `instance_meta` (
+  `id` INTEGER PRIMARY KEY,
+  `import_id` INTEGER NOT NULL REFERENCES `log_import` (`id`),
+  `start_ts` INTEGER NOT NULL,
+  `end_ts` INTEGER,
+  `instance_id` INTEGER NOT NULL,
+  `map_name` TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS `instance_participants` (
+  `instance_meta_id` INTEGER NOT NULL REFERENCES `instance_meta` (`id`),
+  `unit_id` INTEGER NOT NULL REFERENCES `unit` (`id`),
+  PRIMARY KEY (`instance_meta_id`, `unit_id`)
+);
+
+-- Every fight is an attempt, trash fights have no encounter. The fight
+-- number is only unique within an import.
+CREATE TABLE IF NOT EXISTS `instance_attempt` (
+  `id` INTEGER PRIMARY KEY,
+  `import_id` INTEGER NOT NULL REFERENCES `log_import` (`id`),
+  `fight_number` INTEGER NOT NULL,
+  `instance_meta_id` INTEGER NOT NULL REFERENCES `instance_meta` (`id`),
+  `encounter_id` INTEGER REFERENCES `data_encounter` (`id`),
+  `start_ts` INTEGER NOT NULL,
+  `end_ts` INTEGER,
+  `is_kill` INTEGER NOT NULL,
+  `outcome` TEXT NOT NULL,
+  `end_reason` TEXT NOT NULL,
+  UNIQUE (`import_id`, `fight_number`)
+);
+
+CREATE TABLE IF NOT EXISTS `instance_loot` (
+  `id` INTEGER PRIMARY KEY,
+  `import_id` INTEGER NOT NULL REFERENCES `log_import` (`id`),
+  `attempt_id` INTEGER REFERENCES `instance_attempt` (`id`),
+  `unit_id` INTEGER REFERENCES `unit` (`id`),
+  `item_id` INTEGER NOT NULL,
+  `item_name` TEXT NOT NULL,
+  `quality` TEXT NOT NULL,
+  `looted_ts` INTEGER NOT NULL,
+  `amount` INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS `event_damage` (
+  `id` INTEGER PRIMARY KEY,
+  `import_id` INTEGER NOT NULL REFERENCES `log_import` (`id`),
+  `attempt_id` INTEGER REFERENCES `instance_attempt` (`id`),
+  `ts` INTEGER NOT NULL,
+  `caster_id` INTEGER REFERENCES `unit` (`id`),
+  `target_id` INTEGER NOT NULL REFERENCES `unit` (`id`),
+  `spell_id` INTEGER REFERENCES `data_spell` (`id`),
+  `amount` INTEGER NOT NULL,
+  `school` TEXT,
+  `hit_type` INTEGER NOT NULL,
+  `is_crit` INTEGER NOT NULL,
+  `is_fall` INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS `event_heal` (
+  `id` INTEGER PRIMARY KEY,
+  `import_id` INTEGER NOT NULL REFERENCES `log_import` (`id`),
+  `attempt_id` INTEGER REFERENCES `instance_attempt` (`id`),
+  `ts` INTEGER NOT NULL,
+  `caster_id` INTEGER REFERENCES `unit` (`id`),
+  `target_id` INTEGER NOT NULL REFERENCES `unit` (`id`),
+  `spell_id` INTEGER REFERENCES `data_spell` (`id`),
+  `amount` INTEGER NOT NULL,
+  `hit_type` INTEGER NOT NULL,
+  `is_crit` INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS `event_aura` (
+  `id` INTEGER PRIMARY KEY,
+  `import_id` INTEGER NOT NULL REFERENCES `log_import` (`id`),
+  `attempt_id` INTEGER REFERENCES `instance_attempt` (`id`),
+  `ts` INTEGER NOT NULL,
+  `target_id` INTEGER NOT NULL REFERENCES `unit` (`id`),
+  `spell_id` INTEGER REFERENCES `data_spell` (`id`),
+  `stacks` INTEGER NOT NULL,
+  `application` TEXT NOT NULL,
+  `harmful` INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS `event_death` (
+  `id` INTEGER PRIMARY KEY,
+  `import_id` INTEGER NOT NULL REFERENCES `log_import` (`id`),
+  `attempt_id` INTEGER REFERENCES `instance_attempt` (`id`),
+  `ts` INTEGER NOT NULL,
+  `victim_id` INTEGER NOT NULL REFERENCES `unit` (`id`),
+  `killer_id` INTEGER REFERENCES `unit` (`id`)
+);
+
+CREATE INDEX IF NOT EXISTS `ed_attempt_id` ON `event_damage` (`attempt_id`);
+CREATE INDEX IF NOT EXISTS `eh_attempt_id` ON `event_heal` (`attempt_id`);
+CREATE INDEX IF NOT EXISTS `ea_attempt_id` ON `event_aura` (`attempt_id`);
+CREATE INDEX IF NOT EXISTS `edt_attempt_id` ON `event_death` (`attempt_id`);
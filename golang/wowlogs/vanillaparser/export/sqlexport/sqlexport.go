@@ -0,0 +1,357 @@
+// Package sqlexport writes the export model to a SQLite database, for ad-hoc
+// SQL across many logs. It is separate from the export package so the WASM
+// build does not pull in SQLite.
+package sqlexport
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/encounters"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/export"
+
+	_ "modernc.org/sqlite"
+)
+
+//go:embed schema.sql
+var schema string
+
+// Write appends the report and the event rows to the SQLite database at the
+// path, creating it if needed. Each call is a new import, so many logs can be
+// written to the same database.
+func Write(ctx context.Context, path string, r *export.Report, events []export.Event) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	w := &writer{
+		ctx:      ctx,
+		tx:       tx,
+		units:    make(map[string]int64),
+		spells:   make(map[string]int64),
+		attempts: make(map[int]int64),
+	}
+	if err := w.write(r, events); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+type writer struct {
+	ctx context.Context
+	tx  *sql.Tx
+
+	// importID is the row id of the log being written.
+	importID int64
+	// units and spells are the row ids by guid and spell name.
+	units      map[string]int64
+	spells     map[string]int64
+	encounters map[string]int64
+	// attempts are the row ids by fight number.
+	attempts map[int]int64
+}
+
+func (w *writer) write(r *export.Report, events []export.Event) error {
+	if _, err := w.tx.ExecContext(w.ctx, schema); err != nil {
+		return fmt.Errorf("create schema: %w", err)
+	}
+	id, err := w.insert("INSERT INTO log_import (schema_version, me_guid, me_name, imported_ts) VALUES (?, ?, ?, ?)", r.SchemaVersion, r.Me.GUID, r.Me.Name, millis(time.Now()))
+	if err != nil {
+		return fmt.Errorf("log import: %w", err)
+	}
+	w.importID = id
+
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{"encounters", w.writeEncounters},
+		{"units", func() error { return w.writeUnits(r.Units) }},
+		{"fights", func() error { return w.writeFights(r.Fights) }},
+		{"loot", func() error { return w.writeLoot(r.Loot) }},
+		{"events", func() error { return w.writeEvents(events) }},
+	}
+	for _, step := range steps {
+		if err := step.fn(); err != nil {
+			return fmt.Errorf("%s: %w", step.name, err)
+		}
+	}
+	return nil
+}
+
+func (w *writer) exec(query string, args ...any) (sql.Result, error) {
+	return w.tx.ExecContext(w.ctx, query, args...)
+}
+
+func (w *writer) insert(query string, args ...any) (int64, error) {
+	res, err := w.exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// upsert runs an insert that returns the row id, for rows that can already
+// exist from an earlier import.
+func (w *writer) upsert(query string, args ...any) (int64, error) {
+	var id int64
+	err := w.tx.QueryRowContext(w.ctx, query, args...).Scan(&id)
+	return id, err
+}
+
+func (w *writer) writeEncounters() error {
+	w.encounters = make(map[string]int64, len(encounters.Catalog))
+	for _, enc := range encounters.Catalog {
+		id, err := w.upsert("INSERT INTO data_encounter (name, raid) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET raid = excluded.raid RETURNING id", enc.Name, enc.Raid)
+		if err != nil {
+			return err
+		}
+		w.encounters[enc.Name] = id
+
+		for _, npc := range enc.Bosses {
+			if _, err := w.exec("INSERT INTO data_encounter_npcs (encounter_id, npc_id, requires_death) VALUES (?, ?, 1) ON CONFLICT DO UPDATE SET requires_death = 1", id, npc); err != nil {
+				return err
+			}
+		}
+		for _, npc := range enc.Related {
+			if _, err := w.exec("INSERT INTO data_encounter_npcs (encounter_id, npc_id, requires_death) VALUES (?, ?, 0) ON CONFLICT DO UPDATE SET requires_death = 0", id, npc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *writer) writeUnits(units []export.Unit) error {
+	// Units are shared between imports, the latest info wins. Combatant info
+	// is kept if this log does not have it.
+	for _, unit := range units {
+		id, err := w.upsert(`INSERT INTO unit (guid, name, is_player, friendly, hero_class, race, guild) VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (guid) DO UPDATE SET
+				name = excluded.name,
+				is_player = excluded.is_player,
+				friendly = excluded.friendly,
+				hero_class = COALESCE(excluded.hero_class, unit.hero_class),
+				race = COALESCE(excluded.race, unit.race),
+				guild = COALESCE(excluded.guild, unit.guild)
+			RETURNING id`,
+			unit.GUID, unit.Name, unit.IsPlayer, unit.Friendly, nullString(unit.Class), nullString(unit.Race), nullString(unit.Guild))
+		if err != nil {
+			return err
+		}
+		w.units[unit.GUID] = id
+	}
+
+	// Owners are set after every unit has a row.
+	for _, unit := range units {
+		if unit.Owner == "" {
+			continue
+		}
+		owner, err := w.unitID(export.UnitRef{GUID: unit.Owner})
+		if err != nil {
+			return err
+		}
+		if _, err := w.exec("UPDATE unit SET owner_id = ? WHERE id = ?", owner, w.units[unit.GUID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFights writes every fight as an attempt. Consecutive fights in the same
+// zone and instance share an instance row.
+func (w *writer) writeFights(fights []export.Fight) error {
+	var (
+		instance     int64
+		lastZone     string
+		lastInstance uint32
+	)
+	for i, fight := range fights {
+		if i == 0 || fight.Zone != lastZone || fight.InstanceID != lastInstance {
+			var err error
+			instance, err = w.insert("INSERT INTO instance_meta (import_id, start_ts, instance_id, map_name) VALUES (?, ?, ?, ?)", w.importID, millis(fight.Start), fight.InstanceID, fight.Zone)
+			if err != nil {
+				return err
+			}
+			lastZone, lastInstance = fight.Zone, fight.InstanceID
+		}
+		if !fight.End.IsZero() {
+			if _, err := w.exec("UPDATE instance_meta SET end_ts = ? WHERE id = ?", millis(fight.End), instance); err != nil {
+				return err
+			}
+		}
+
+		var encounter *int64
+		if id, ok := w.encounters[fight.Encounter]; ok {
+			encounter = &id
+		}
+		var end *int64
+		if !fight.End.IsZero() {
+			ts := millis(fight.End)
+			end = &ts
+		}
+		attempt, err := w.insert("INSERT INTO instance_attempt (import_id, fight_number, instance_meta_id, encounter_id, start_ts, end_ts, is_kill, outcome, end_reason) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			w.importID, fight.Number, instance, encounter, millis(fight.Start), end, fight.Outcome == "kill", fight.Outcome, fight.EndReason)
+		if err != nil {
+			return err
+		}
+		w.attempts[fight.Number] = attempt
+
+		for _, guid := range fight.Participants {
+			unit, err := w.unitID(export.UnitRef{GUID: guid})
+			if err != nil {
+				return err
+			}
+			if _, err := w.exec("INSERT OR IGNORE INTO instance_participants (instance_meta_id, unit_id) VALUES (?, ?)", instance, unit); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *writer) writeLoot(loot []export.Loot) error {
+	for _, drop := range loot {
+		attempt := w.attemptID(drop.FightNumber)
+		var unit *int64
+		if drop.Receiver.GUID != "" && drop.Receiver.GUID != zeroGUID {
+			id, err := w.unitID(drop.Receiver)
+			if err != nil {
+				return err
+			}
+			unit = &id
+		}
+		_, err := w.exec("INSERT INTO instance_loot (import_id, attempt_id, unit_id, item_id, item_name, quality, looted_ts, amount) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+			w.importID, attempt, unit, drop.ItemID, drop.ItemName, drop.Quality, millis(drop.At), drop.Count)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *writer) writeEvents(events []export.Event) error {
+	for _, event := range events {
+		if !stored[event.Type] {
+			continue
+		}
+
+		attempt := w.attemptID(event.Fight)
+		target, err := w.unitID(event.Target)
+		if err != nil {
+			return err
+		}
+		var caster *int64
+		if event.Caster != nil {
+			id, err := w.unitID(*event.Caster)
+			if err != nil {
+				return err
+			}
+			caster = &id
+		}
+		spell, err := w.spellID(event.SpellName)
+		if err != nil {
+			return err
+		}
+
+		switch event.Type {
+		case export.EventTypeDamage, export.EventTypeFallDamage:
+			_, err = w.exec("INSERT INTO event_damage (import_id, attempt_id, ts, caster_id, target_id, spell_id, amount, school, hit_type, is_crit, is_fall) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				w.importID, attempt, millis(event.At), caster, target, spell, event.Amount, nullString(event.School), event.HitType, event.Crit, event.Type == export.EventTypeFallDamage)
+		case export.EventTypeHeal:
+			_, err = w.exec("INSERT INTO event_heal (import_id, attempt_id, ts, caster_id, target_id, spell_id, amount, hit_type, is_crit) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				w.importID, attempt, millis(event.At), caster, target, spell, event.Amount, event.HitType, event.Crit)
+		case export.EventTypeAura:
+			_, err = w.exec("INSERT INTO event_aura (import_id, attempt_id, ts, target_id, spell_id, stacks, application, harmful) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+				w.importID, attempt, millis(event.At), target, spell, event.Amount, event.Application, event.Harmful)
+		case export.EventTypeDeath:
+			_, err = w.exec("INSERT INTO event_death (import_id, attempt_id, ts, victim_id, killer_id) VALUES (?, ?, ?, ?, ?)",
+				w.importID, attempt, millis(event.At), target, caster)
+		}
+		if err != nil {
+			return fmt.Errorf("%s event: %w", event.Type, err)
+		}
+	}
+	return nil
+}
+
+// stored are the event types with a table. Casts, resources and the rest are
+// only in the row exports.
+var stored = map[export.EventType]bool{
+	export.EventTypeDamage:     true,
+	export.EventTypeFallDamage: true,
+	export.EventTypeHeal:       true,
+	export.EventTypeAura:       true,
+	export.EventTypeDeath:      true,
+}
+
+// zeroGUID is the guid of units that are only known by name.
+const zeroGUID = "0x0000000000000000"
+
+// attemptID returns the row id of the fight, or nil for rows outside a fight.
+func (w *writer) attemptID(fight int) *int64 {
+	id, ok := w.attempts[fight]
+	if !ok {
+		return nil
+	}
+	return &id
+}
+
+// unitID returns the row id of the unit, inserting it if it was never seen in
+// a unit info line. A unit from an earlier import only gets the name if it
+// had none.
+func (w *writer) unitID(ref export.UnitRef) (int64, error) {
+	if id, ok := w.units[ref.GUID]; ok {
+		return id, nil
+	}
+	id, err := w.upsert(`INSERT INTO unit (guid, name, is_player, friendly) VALUES (?, ?, 0, 0)
+		ON CONFLICT (guid) DO UPDATE SET name = CASE WHEN unit.name = '' THEN excluded.name ELSE unit.name END
+		RETURNING id`, ref.GUID, ref.Name)
+	if err != nil {
+		return 0, err
+	}
+	w.units[ref.GUID] = id
+	return id, nil
+}
+
+// spellID returns the row id of the spell, or nil for events without a
+// spell, like melee.
+func (w *writer) spellID(name string) (*int64, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if id, ok := w.spells[name]; ok {
+		return &id, nil
+	}
+	id, err := w.upsert("INSERT INTO data_spell (name) VALUES (?) ON CONFLICT (name) DO UPDATE SET name = excluded.name RETURNING id", name)
+	if err != nil {
+		return nil, err
+	}
+	w.spells[name] = id
+	return &id, nil
+}
+
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func millis(ts time.Time) int64 {
+	return ts.UnixMilli()
+}
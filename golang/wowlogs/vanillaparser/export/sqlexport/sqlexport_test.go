@@ -0,0 +1,178 @@
+package sqlexport_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/encounters"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/export"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/export/sqlexport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 10, 17, 21, 0, 0, 0, time.UTC)
+	warrior := export.UnitRef{GUID: "0x0000000000062A1B", Name: "Tankman"}
+	patchwerk := export.UnitRef{GUID: "0xF130003E9C000001", Name: "Patchwerk"}
+	wolf := export.UnitRef{GUID: "0xF140000000000001", Name: "Wolf"}
+
+	report := &export.Report{
+		SchemaVersion: export.SchemaVersion,
+		Me:            warrior,
+		Units: []export.Unit{
+			{UnitRef: warrior, IsPlayer: true, Friendly: true, Class: "Warrior"},
+			{UnitRef: patchwerk},
+		},
+		Fights: []export.Fight{
+			{Number: 1, Zone: "Naxxramas", InstanceID: 7, Start: start, End: start.Add(time.Minute), Outcome: "reset", EndReason: "timeout", Participants: []string{warrior.GUID, wolf.GUID}},
+			{Number: 2, Zone: "Naxxramas", InstanceID: 7, Encounter: "Patchwerk", Start: start.Add(5 * time.Minute), End: start.Add(8 * time.Minute), Outcome: "kill", EndReason: "kill", Participants: []string{warrior.GUID, patchwerk.GUID}},
+		},
+		Loot: []export.Loot{
+			{At: start.Add(9 * time.Minute), Receiver: warrior, ItemID: 22820, ItemName: "Wand of Fates", Quality: "epic", Count: 1, FightNumber: 2, Encounter: "Patchwerk"},
+		},
+	}
+	events := []export.Event{
+		{Type: export.EventTypeDamage, At: start.Add(6 * time.Minute), Fight: 2, Caster: &patchwerk, Target: warrior, SpellName: "Hateful Strike", Amount: 3000, School: "physical", HitType: 2},
+		{Type: export.EventTypeDamage, At: start.Add(6 * time.Minute), Fight: 2, Caster: &warrior, Target: patchwerk, Amount: 500, HitType: 4, Crit: true},
+		{Type: export.EventTypeHeal, At: start.Add(7 * time.Minute), Fight: 2, Caster: &warrior, Target: warrior, SpellName: "Healing Potion", Amount: 1500, HitType: 2},
+		{Type: export.EventTypeAura, At: start.Add(7 * time.Minute), Fight: 2, Target: warrior, SpellName: "Hateful Strike", Amount: 1, Application: "gains", Harmful: true},
+		{Type: export.EventTypeDeath, At: start.Add(8 * time.Minute), Fight: 2, Caster: &warrior, Target: patchwerk},
+		{Type: export.EventTypeFallDamage, At: start.Add(10 * time.Minute), Target: warrior, Amount: 200},
+	}
+
+	path := filepath.Join(t.TempDir(), "raid.db")
+	require.NoError(t, sqlexport.Write(context.Background(), path, report, events))
+
+	db, count := openDB(t, path)
+
+	require.Equal(t, 1, count("SELECT COUNT(*) FROM instance_meta"))
+	require.Equal(t, 2, count("SELECT COUNT(*) FROM instance_attempt"))
+	require.Equal(t, 1, count("SELECT COUNT(*) FROM instance_attempt a JOIN data_encounter e ON a.encounter_id = e.id WHERE e.name = 'Patchwerk' AND a.is_kill = 1"))
+	require.Equal(t, 3, count("SELECT COUNT(*) FROM instance_participants"))
+	require.Equal(t, 3, count("SELECT COUNT(*) FROM unit"), "the wolf is added from the participants")
+	require.Equal(t, 3, count("SELECT COUNT(*) FROM event_damage"))
+	require.Equal(t, 1, count("SELECT COUNT(*) FROM event_damage WHERE is_fall = 1 AND attempt_id IS NULL"))
+	require.Equal(t, 1, count("SELECT COUNT(*) FROM event_damage WHERE spell_id IS NULL AND is_fall = 0"), "melee has no spell")
+	require.Equal(t, 1, count("SELECT COUNT(*) FROM event_heal"))
+	require.Equal(t, 1, count("SELECT COUNT(*) FROM event_aura"))
+	require.Equal(t, 2, count("SELECT COUNT(*) FROM data_spell"))
+	require.Equal(t, 1, count("SELECT COUNT(*) FROM instance_loot l JOIN instance_attempt a ON l.attempt_id = a.id WHERE a.fight_number = 2"))
+
+	var victim string
+	require.NoError(t, db.QueryRow("SELECT u.name FROM event_death d JOIN unit u ON d.victim_id = u.id").Scan(&victim))
+	require.Equal(t, "Patchwerk", victim)
+
+	var total int
+	require.NoError(t, db.QueryRow("SELECT SUM(amount) FROM event_damage d JOIN unit u ON d.target_id = u.id WHERE u.name = ?", "Tankman").Scan(&total))
+	require.Equal(t, 3200, total)
+}
+
+func TestWriteAppend(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 10, 17, 21, 0, 0, 0, time.UTC)
+	warrior := export.UnitRef{GUID: "0x0000000000062A1B", Name: "Tankman"}
+	priest := export.UnitRef{GUID: "0x000000000001C7AC", Name: "Doyd"}
+	patchwerk := export.UnitRef{GUID: "0xF130003E9C000001", Name: "Patchwerk"}
+	grobbulus := export.UnitRef{GUID: "0xF130003C44000002", Name: "Grobbulus"}
+
+	// Two logs of the same raid week, both start at fight 1
+	first := &export.Report{
+		SchemaVersion: export.SchemaVersion,
+		Me:            warrior,
+		Units: []export.Unit{
+			{UnitRef: warrior, IsPlayer: true, Friendly: true, Class: "Warrior"},
+			{UnitRef: patchwerk},
+		},
+		Fights: []export.Fight{
+			{Number: 1, Zone: "Naxxramas", InstanceID: 7, Encounter: "Patchwerk", Start: start, End: start.Add(3 * time.Minute), Outcome: "kill", EndReason: "kill", Participants: []string{warrior.GUID, patchwerk.GUID}},
+		},
+		Loot: []export.Loot{
+			{At: start.Add(4 * time.Minute), Receiver: warrior, ItemID: 22820, ItemName: "Wand of Fates", Quality: "epic", Count: 1, FightNumber: 1, Encounter: "Patchwerk"},
+		},
+	}
+	firstEvents := []export.Event{
+		{Type: export.EventTypeDamage, At: start.Add(time.Minute), Fight: 1, Caster: &patchwerk, Target: warrior, SpellName: "Hateful Strike", Amount: 3000, School: "physical", HitType: 2},
+	}
+
+	later := start.Add(24 * time.Hour)
+	second := &export.Report{
+		SchemaVersion: export.SchemaVersion,
+		Me:            priest,
+		Units: []export.Unit{
+			// The warrior is seen again without combatant info
+			{UnitRef: warrior, IsPlayer: true, Friendly: true},
+			{UnitRef: priest, IsPlayer: true, Friendly: true, Class: "Priest"},
+			{UnitRef: grobbulus},
+		},
+		Fights: []export.Fight{
+			{Number: 1, Zone: "Naxxramas", InstanceID: 8, Encounter: "Grobbulus", Start: later, End: later.Add(2 * time.Minute), Outcome: "kill", EndReason: "kill", Participants: []string{warrior.GUID, priest.GUID, grobbulus.GUID}},
+		},
+		Loot: []export.Loot{
+			{At: later.Add(3 * time.Minute), Receiver: priest, ItemID: 22810, ItemName: "Toxin Injector", Quality: "epic", Count: 1, FightNumber: 1, Encounter: "Grobbulus"},
+		},
+	}
+	secondEvents := []export.Event{
+		{Type: export.EventTypeDamage, At: later.Add(time.Minute), Fight: 1, Caster: &grobbulus, Target: warrior, SpellName: "Hateful Strike", Amount: 100, School: "physical", HitType: 2},
+		{Type: export.EventTypeDamage, At: later.Add(time.Minute), Fight: 1, Caster: &grobbulus, Target: priest, SpellName: "Poison Cloud", Amount: 200, School: "nature", HitType: 2},
+	}
+
+	path := filepath.Join(t.TempDir(), "raid.db")
+	require.NoError(t, sqlexport.Write(context.Background(), path, first, firstEvents))
+	require.NoError(t, sqlexport.Write(context.Background(), path, second, secondEvents))
+
+	db, count := openDB(t, path)
+
+	require.Equal(t, 2, count("SELECT COUNT(*) FROM log_import"))
+	require.Equal(t, 2, count("SELECT COUNT(*) FROM instance_meta"))
+	require.Equal(t, 2, count("SELECT COUNT(DISTINCT id) FROM instance_attempt WHERE fight_number = 1"))
+	require.Equal(t, len(encounters.Catalog), count("SELECT COUNT(*) FROM data_encounter"))
+	require.Equal(t, 4, count("SELECT COUNT(*) FROM unit"), "the warrior is shared")
+	require.Equal(t, 2, count("SELECT COUNT(*) FROM data_spell"), "Hateful Strike is shared")
+
+	var class string
+	require.NoError(t, db.QueryRow("SELECT hero_class FROM unit WHERE guid = ?", warrior.GUID).Scan(&class))
+	require.Equal(t, "Warrior", class, "combatant info is kept")
+
+	// Loot and events point to the attempt of their own import
+	rows, err := db.Query(`SELECT l.item_name, e.name FROM instance_loot l
+		JOIN instance_attempt a ON l.attempt_id = a.id
+		JOIN data_encounter e ON a.encounter_id = e.id
+		WHERE a.import_id = l.import_id ORDER BY l.looted_ts`)
+	require.NoError(t, err)
+	defer func() { _ = rows.Close() }()
+	loot := map[string]string{}
+	for rows.Next() {
+		var item, encounter string
+		require.NoError(t, rows.Scan(&item, &encounter))
+		loot[item] = encounter
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, map[string]string{"Wand of Fates": "Patchwerk", "Toxin Injector": "Grobbulus"}, loot)
+
+	require.Equal(t, 2, count(`SELECT COUNT(*) FROM event_damage d
+		JOIN instance_attempt a ON d.attempt_id = a.id
+		JOIN data_encounter e ON a.encounter_id = e.id
+		WHERE e.name = 'Grobbulus' AND d.import_id = a.import_id`))
+}
+
+func openDB(t *testing.T, path string) (*sql.DB, func(query string, args ...any) int) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	count := func(query string, args ...any) int {
+		t.Helper()
+		var n int
+		require.NoError(t, db.QueryRow(query, args...).Scan(&n))
+		return n
+	}
+	return db, count
+}
@@ -44,12 +44,13 @@ func (p *Parser) fLoot(ts time.Time, content string) ([]messages.Message, error)
 
 	li, err := loot.ParseLootInfo(content)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse zone info: %v", err)
+		return nil, fmt.Errorf("failed to parse loot info: %v", err)
 	}
 
-	var _ = li
-
-	return messages.Skip(ts, "loot info"), nil
+	return set(messages.Loot{
+		MessageBase: messages.Base(ts),
+		Loot:        li,
+	}), nil
 }
 
 func (p *Parser) fZoneInfo(ts time.Time, content string) ([]messages.Message, error) {
@@ -384,7 +385,7 @@ func (p *Parser) fAuraGainHarmfulHelpful(ts time.Time, content string) ([]messag
 	}
 
 	_, target := matches.UnitOrGUID()
-	matches.Skip()
+	harmful := matches.String() == "is afflicted by"
 	spellName := matches.String()
 	amount := matches.Int32()
 	if err := matches.Error(); err != nil {
@@ -401,6 +402,7 @@ func (p *Parser) fAuraGainHarmfulHelpful(ts time.Time, content string) ([]messag
 		SpellName:   spellName,
 		Amount:      amount,
 		Application: types.AuraApplicationGains,
+		Harmful:     harmful,
 	}), nil
 }
 
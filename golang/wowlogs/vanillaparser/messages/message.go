@@ -7,6 +7,7 @@ import (
 	"github.com/Emyrk/chronicle/golang/wowlogs/types"
 	"github.com/Emyrk/chronicle/golang/wowlogs/types/castv2"
 	"github.com/Emyrk/chronicle/golang/wowlogs/types/combatant"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/loot"
 	"github.com/Emyrk/chronicle/golang/wowlogs/types/unitinfo"
 	"github.com/Emyrk/chronicle/golang/wowlogs/types/zone"
 )
@@ -91,6 +92,11 @@ type Zone struct {
 	zone.Zone
 }
 
+type Loot struct {
+	MessageBase
+	loot.Loot
+}
+
 type ResourceChange struct {
 	MessageBase
 	Target    guid.GUID
@@ -139,10 +145,14 @@ type Slain struct {
 
 type Aura struct {
 	MessageBase
-	Target      guid.GUID
-	SpellName   string
+	Target    guid.GUID
+	SpellName string
+	// Amount is the number of stacks on gains.
 	Amount      int32
 	Application types.AuraApplication
+	// Harmful is true for debuffs ("is afflicted by"). It is only known
+	// when the aura is gained.
+	Harmful bool
 }
 
 type Interrupt struct {
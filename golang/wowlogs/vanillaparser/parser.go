@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/Emyrk/chronicle/golang/wowlogs/diagnostics"
 	"github.com/Emyrk/chronicle/golang/wowlogs/lines"
 	"github.com/Emyrk/chronicle/golang/wowlogs/merge"
 	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
@@ -25,6 +26,9 @@ type Parser struct {
 	state   *state.State
 	you     *youReplacer
 
+	stateOpts []state.Option
+	diag      *diagnostics.Report
+
 	setup       sync.Once
 	lastLogDate time.Time
 }
@@ -49,9 +53,20 @@ func (p *Parser) State() *state.State {
 	return p.state
 }
 
+// SetStateOptions configures the state that is created once the parser has
+// identified the player. Must be called before the first Advance.
+func (p *Parser) SetStateOptions(opts ...state.Option) {
+	p.stateOpts = opts
+}
+
+// SetDiagnostics counts the unparsed and skipped lines in the report.
+func (p *Parser) SetDiagnostics(report *diagnostics.Report) {
+	p.diag = report
+}
+
 // Merger returns a configured merger for this parser.
-func Merger(logger *slog.Logger) *merge.Merger {
-	return merge.NewMerger(logger) //merge.WithMiddleWare(OnlyKeepRawV2Casts),
+func Merger(logger *slog.Logger, opts ...merge.Option) *merge.Merger {
+	return merge.NewMerger(logger, opts...) //merge.WithMiddleWare(OnlyKeepRawV2Casts),
 }
 
 func (p *Parser) init() error {
@@ -68,7 +83,7 @@ func (p *Parser) init() error {
 			slog.String("guid", me.Gid.String()),
 			slog.Int("lines_read", lc),
 		)
-		p.state = state.NewState(p.logger, me)
+		p.state = state.NewState(p.logger, me, p.stateOpts...)
 		p.scanner = scan
 		p.you = &youReplacer{Me: me}
 	})
@@ -103,13 +118,16 @@ func (p *Parser) Advance() ([]messages.Message, error) {
 	if content == "" {
 		// Maybe the preprocessing removed all content, it does not matter.
 		// Empty lines are not interesting.
-		return messages.Skip(ts, "empty line"), nil
+		msgs := messages.Skip(ts, "empty line")
+		p.diagnose(ts, content, msgs)
+		return msgs, nil
 	}
 
 	msgs, err := p.parseContent(ts, content)
 	if err != nil {
 		return nil, err
 	}
+	p.diagnose(ts, content, msgs)
 
 	for _, msg := range msgs {
 		if msg.Date().IsZero() {
@@ -185,6 +203,22 @@ func (p *Parser) parseContent(ts time.Time, content string) ([]messages.Message,
 	}), nil
 }
 
+// diagnose adds the line to the diagnostics if it was not used.
+func (p *Parser) diagnose(ts time.Time, content string, msgs []messages.Message) {
+	if p.diag == nil {
+		return
+	}
+
+	for _, msg := range msgs {
+		switch typed := msg.(type) {
+		case messages.UnparsedLine, *messages.UnparsedLine:
+			p.diag.AddUnparsed(p.liner.FmtLine(ts, content))
+		case messages.SkippedMessage:
+			p.diag.AddSkipped(typed.Reason, p.liner.FmtLine(ts, content))
+		}
+	}
+}
+
 func set(m ...messages.Message) []messages.Message {
 	return m
 }
@@ -0,0 +1,207 @@
+package state
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+)
+
+// Auras keeps track of the buffs and debuffs on every unit for the whole log.
+// Auras are not reset between fights, as buffs are often applied before a
+// fight starts.
+type Auras struct {
+	// Units is keyed by the unit with the aura, then by the aura's spell name.
+	Units map[guid.GUID]map[string]*AuraTrack
+}
+
+// AuraTrack is the history of a single aura on a single unit.
+type AuraTrack struct {
+	Harmful bool
+	// Open is the currently active interval, if any.
+	Open   *AuraInterval
+	Closed []AuraInterval
+}
+
+// AuraInterval is a span of time an aura was active with the same number of
+// stacks. A change in stacks closes the interval and opens a new one.
+type AuraInterval struct {
+	Start  time.Time
+	End    time.Time
+	Stacks int32
+}
+
+// AuraUptime is how long an aura was active on a unit within a time window.
+type AuraUptime struct {
+	Target    guid.GUID
+	SpellName string
+	Harmful   bool
+	Uptime    time.Duration
+	// Percent is the uptime as a percentage (0-100) of the window.
+	Percent float64
+}
+
+func NewAuras() *Auras {
+	return &Auras{
+		Units: make(map[guid.GUID]map[string]*AuraTrack),
+	}
+}
+
+func (a *Auras) Process(m messages.Aura) {
+	switch m.Application {
+	case types.AuraApplicationGains:
+		a.Gain(m.Date(), m.Target, m.SpellName, m.Amount, m.Harmful)
+	case types.AuraApplicationFades, types.AuraApplicationRemoved:
+		a.Fade(m.Date(), m.Target, m.SpellName)
+	}
+}
+
+func (a *Auras) track(target guid.GUID, spellName string) *AuraTrack {
+	unit, ok := a.Units[target]
+	if !ok {
+		unit = make(map[string]*AuraTrack)
+		a.Units[target] = unit
+	}
+
+	t, ok := unit[spellName]
+	if !ok {
+		t = &AuraTrack{}
+		unit[spellName] = t
+	}
+	return t
+}
+
+// Gain opens an aura interval. Gaining an aura that is already active with the
+// same stacks is a refresh, and does not change the interval.
+func (a *Auras) Gain(ts time.Time, target guid.GUID, spellName string, stacks int32, harmful bool) {
+	t := a.track(target, spellName)
+	t.Harmful = harmful
+
+	if t.Open != nil {
+		if t.Open.Stacks == stacks {
+			return
+		}
+		t.close(ts)
+	}
+
+	t.Open = &AuraInterval{
+		Start:  ts,
+		Stacks: stacks,
+	}
+}
+
+// Fade closes the aura interval. Fades for auras that were never seen gained
+// are ignored, as the start of the aura is unknown.
+func (a *Auras) Fade(ts time.Time, target guid.GUID, spellName string) {
+	unit, ok := a.Units[target]
+	if !ok {
+		return
+	}
+	t, ok := unit[spellName]
+	if !ok {
+		return
+	}
+	t.close(ts)
+}
+
+// Active returns true if the aura is currently on the unit.
+func (a *Auras) Active(target guid.GUID, spellName string) bool {
+	t, ok := a.Units[target][spellName]
+	return ok && t.Open != nil
+}
+
+// ClearUnit closes all open auras on the unit, for example when it dies.
+func (a *Auras) ClearUnit(ts time.Time, target guid.GUID) {
+	for _, t := range a.Units[target] {
+		t.close(ts)
+	}
+}
+
+func (t *AuraTrack) close(ts time.Time) {
+	if t.Open == nil {
+		return
+	}
+	interval := *t.Open
+	interval.End = ts
+	t.Closed = append(t.Closed, interval)
+	t.Open = nil
+}
+
+// ActiveAt returns the interval that was active at the time, if any.
+func (t *AuraTrack) ActiveAt(ts time.Time) (AuraInterval, bool) {
+	if t.Open != nil && !t.Open.Start.After(ts) {
+		return *t.Open, true
+	}
+	for _, interval := range t.Closed {
+		if !interval.Start.After(ts) && interval.End.After(ts) {
+			return interval, true
+		}
+	}
+	return AuraInterval{}, false
+}
+
+// Uptime returns how long the aura was active between start and end. An aura
+// that is still open is considered active until end.
+func (t *AuraTrack) Uptime(start, end time.Time) time.Duration {
+	var total time.Duration
+	add := func(iStart, iEnd time.Time) {
+		if iStart.Before(start) {
+			iStart = start
+		}
+		if iEnd.After(end) {
+			iEnd = end
+		}
+		if iEnd.After(iStart) {
+			total += iEnd.Sub(iStart)
+		}
+	}
+
+	for _, interval := range t.Closed {
+		add(interval.Start, interval.End)
+	}
+	if t.Open != nil {
+		add(t.Open.Start, end)
+	}
+	return total
+}
+
+// Uptimes returns the uptime of every aura that was active at some point
+// between start and end. If include is nil, all units are included.
+func (a *Auras) Uptimes(start, end time.Time, include func(id guid.GUID) bool) []AuraUptime {
+	window := end.Sub(start)
+	if window <= 0 {
+		return nil
+	}
+
+	var uptimes []AuraUptime
+	for target, tracks := range a.Units {
+		if include != nil && !include(target) {
+			continue
+		}
+
+		for spellName, t := range tracks {
+			up := t.Uptime(start, end)
+			if up <= 0 {
+				continue
+			}
+			uptimes = append(uptimes, AuraUptime{
+				Target:    target,
+				SpellName: spellName,
+				Harmful:   t.Harmful,
+				Uptime:    up,
+				Percent:   float64(up) / float64(window) * 100,
+			})
+		}
+	}
+
+	slices.SortFunc(uptimes, func(a, b AuraUptime) int {
+		if c := cmp.Compare(a.Target, b.Target); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.SpellName, b.SpellName)
+	})
+	return uptimes
+}
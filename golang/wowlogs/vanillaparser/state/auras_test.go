@@ -0,0 +1,69 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/internal/ptr"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuras(t *testing.T) {
+	t.Parallel()
+
+	ts := func(sec int) time.Time { return testStart.Add(time.Duration(sec) * time.Second) }
+
+	t.Run("Stacks", func(t *testing.T) {
+		t.Parallel()
+
+		a := NewAuras()
+		a.Gain(ts(0), testBoss, "Sunder Armor", 1, true)
+		a.Gain(ts(2), testBoss, "Sunder Armor", 2, true)
+		// Refresh with the same stacks keeps the interval
+		a.Gain(ts(4), testBoss, "Sunder Armor", 2, true)
+		a.Fade(ts(10), testBoss, "Sunder Armor")
+
+		track := a.Units[testBoss]["Sunder Armor"]
+		require.Nil(t, track.Open)
+		require.Equal(t, []AuraInterval{
+			{Start: ts(0), End: ts(2), Stacks: 1},
+			{Start: ts(2), End: ts(10), Stacks: 2},
+		}, track.Closed)
+		require.Equal(t, 10*time.Second, track.Uptime(ts(0), ts(20)))
+		require.Equal(t, 5*time.Second, track.Uptime(ts(5), ts(20)))
+	})
+
+	t.Run("FadeWithoutGain", func(t *testing.T) {
+		t.Parallel()
+
+		a := NewAuras()
+		a.Fade(ts(1), testBoss, "Unknown")
+		require.Empty(t, a.Uptimes(ts(0), ts(10), nil))
+	})
+
+	t.Run("FightUptime", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestState(t)
+		process(t, s,
+			messages.Aura{MessageBase: at(0), Target: testWarrior, SpellName: "Battle Shout", Amount: 1, Application: types.AuraApplicationGains},
+			messages.Damage{MessageBase: at(10), Caster: testWarrior, Target: testBoss, Amount: 100, HitType: types.HitTypeHit},
+			messages.Aura{MessageBase: at(12), Target: testBoss, SpellName: "Rend", Amount: 1, Application: types.AuraApplicationGains, Harmful: true},
+			messages.Damage{MessageBase: at(13), Caster: testBoss, Target: testWarrior, Amount: 100, HitType: types.HitTypeHit},
+			messages.Aura{MessageBase: at(15), Target: testWarrior, SpellName: "Battle Shout", Application: types.AuraApplicationFades},
+			messages.Slain{MessageBase: at(20), Victim: testBoss, Killer: ptr.Ref(testWarrior)},
+		)
+
+		fight := s.Fights.Fights[0]
+		require.True(t, fight.IsDone())
+		require.Equal(t, []AuraUptime{
+			{Target: testWarrior, SpellName: "Battle Shout", Harmful: false, Uptime: 5 * time.Second, Percent: 50},
+			{Target: testBoss, SpellName: "Rend", Harmful: true, Uptime: 8 * time.Second, Percent: 80},
+		}, fight.AuraUptimes())
+
+		// Death clears the auras
+		require.Nil(t, s.Auras.Units[testBoss]["Rend"].Open)
+	})
+}
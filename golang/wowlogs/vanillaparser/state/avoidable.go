@@ -0,0 +1,72 @@
+package state
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/encounters"
+)
+
+// AvoidableHit is the avoidable damage a single player took from one spell.
+type AvoidableHit struct {
+	Player    guid.GUID
+	SpellName string
+	// Hits is the number of times the player took damage. Misses and full
+	// resists are not counted.
+	Hits  int
+	Total int64
+}
+
+// AvoidableSpells returns the avoidable spell names for the fight's encounter,
+// including those that apply to every fight.
+func (f *Fight) AvoidableSpells() map[string]bool {
+	spells := make(map[string]bool)
+	for _, name := range f.s.avoidable[encounters.AnyEncounter] {
+		spells[name] = true
+	}
+	if f.Encounter != nil {
+		for _, name := range f.s.avoidable[f.Encounter.Name] {
+			spells[name] = true
+		}
+	}
+	return spells
+}
+
+// AvoidableDamage returns the avoidable damage taken by friendly players in
+// the fight, most damage first.
+func (f *Fight) AvoidableDamage() []AvoidableHit {
+	spells := f.AvoidableSpells()
+	if len(spells) == 0 {
+		return nil
+	}
+
+	var hits []AvoidableHit
+	for id, taken := range f.DamageMeter.Taken {
+		if !id.IsPlayer() || !f.s.IsFriendly(id) {
+			continue
+		}
+		for name, spell := range taken.BySpell {
+			if !spells[name] || spell.Hits == 0 {
+				continue
+			}
+			hits = append(hits, AvoidableHit{
+				Player:    id,
+				SpellName: name,
+				Hits:      spell.Hits,
+				Total:     spell.Total,
+			})
+		}
+	}
+
+	slices.SortFunc(hits, func(a, b AvoidableHit) int {
+		if c := cmp.Compare(b.Total, a.Total); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Player, b.Player); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.SpellName, b.SpellName)
+	})
+	return hits
+}
@@ -0,0 +1,66 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/Emyrk/chronicle/golang/internal/testutil"
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/unitinfo"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/encounters"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvoidableDamage(t *testing.T) {
+	t.Parallel()
+
+	patchwerk := creature(16028, 1)
+	hit := func(sec float64, spell string, target guid.GUID, amount int32, hitType types.HitType) messages.Damage {
+		return messages.Damage{MessageBase: at(sec), SpellName: &spell, Caster: patchwerk, Target: target, Amount: amount, HitType: hitType}
+	}
+	msgs := []messages.Message{
+		messages.Unit{MessageBase: at(0), Info: unitinfo.Info{Guid: patchwerk, Name: "Patchwerk"}},
+		messages.Damage{MessageBase: at(1), Caster: testWarrior, Target: patchwerk, Amount: 100, HitType: types.HitTypeHit},
+		hit(2, "Hateful Strike", testWarrior, 2000, types.HitTypeHit),
+		hit(3, "Hateful Strike", testWarrior, 0, types.HitTypeMiss),
+		hit(4, "Hateful Strike", testWarrior, 3000, types.HitTypeCrit),
+		hit(5, "Poison Charge", testPriest, 500, types.HitTypeHit),
+		hit(6, "Slimebolt", testPriest, 4000, types.HitTypeHit),
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestState(t)
+		process(t, s, msgs...)
+
+		fight := s.Fights.CurrentFight
+		require.Equal(t, "Patchwerk", fight.EncounterName())
+		require.Equal(t, []AvoidableHit{
+			{Player: testWarrior, SpellName: "Hateful Strike", Hits: 2, Total: 5000},
+			{Player: testPriest, SpellName: "Poison Charge", Hits: 1, Total: 500},
+		}, fight.AvoidableDamage())
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewState(testutil.Logger(t), types.Unit{Name: "Doyd", Gid: testPriest}, WithAvoidableDamage(map[string][]string{
+			"Patchwerk": {"Slimebolt"},
+		}))
+		for _, info := range []unitinfo.Info{
+			{Guid: testPriest, Name: "Doyd", IsPlayer: true, CanCooperate: true},
+			{Guid: testWarrior, Name: "Tankman", IsPlayer: true, CanCooperate: true},
+		} {
+			require.NoError(t, s.Process(messages.Unit{MessageBase: at(0), Info: info}))
+		}
+		process(t, s, msgs...)
+
+		require.Equal(t, []AvoidableHit{
+			{Player: testPriest, SpellName: "Slimebolt", Hits: 1, Total: 4000},
+		}, s.Fights.CurrentFight.AvoidableDamage())
+		_, ok := s.avoidable[encounters.AnyEncounter]
+		require.False(t, ok)
+	})
+}
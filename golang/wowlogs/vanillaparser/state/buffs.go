@@ -0,0 +1,120 @@
+package state
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/consumables"
+)
+
+// worldBuffs are the aura names of buffs from world events and zones.
+var worldBuffs = map[string]bool{
+	"Rallying Cry of the Dragonslayer": true,
+	"Songflower Serenade":              true,
+	"Warchief's Blessing":              true,
+	"Spirit of Zandalar":               true,
+	// Dire Maul tribute
+	"Fengus' Ferocity": true,
+	"Mol'dar's Moxie":  true,
+	"Slip'kik's Savvy": true,
+	// Darkmoon Faire
+	"Sayge's Dark Fortune of Damage":       true,
+	"Sayge's Dark Fortune of Agility":      true,
+	"Sayge's Dark Fortune of Intelligence": true,
+	"Sayge's Dark Fortune of Spirit":       true,
+	"Sayge's Dark Fortune of Stamina":      true,
+	"Sayge's Dark Fortune of Strength":     true,
+	"Sayge's Dark Fortune of Armor":        true,
+	"Sayge's Dark Fortune of Resistance":   true,
+}
+
+// IsWorldBuff returns true if the aura is a world buff.
+func IsWorldBuff(spellName string) bool {
+	return worldBuffs[spellName]
+}
+
+// ActiveBuff is a buff on a player when the fight started.
+type ActiveBuff struct {
+	Unit      guid.GUID
+	SpellName string
+	// Since is when the buff was gained.
+	Since  time.Time
+	Stacks int32
+	// WorldBuff and Consumable are set if the buff is a known world buff or
+	// consumable.
+	WorldBuff  bool
+	Consumable bool
+}
+
+// snapshotBuffs returns the buffs active on every friendly player at the time.
+// Auras are tracked for the whole log, so buffs gained long before the fight
+// are included.
+func (s *State) snapshotBuffs(ts time.Time) []ActiveBuff {
+	var buffs []ActiveBuff
+	for id, tracks := range s.Auras.Units {
+		if !id.IsPlayer() || !s.IsFriendly(id) {
+			continue
+		}
+		for spellName, track := range tracks {
+			if track.Harmful {
+				continue
+			}
+			interval, ok := track.ActiveAt(ts)
+			if !ok {
+				continue
+			}
+			_, consumable := consumables.ByName(spellName)
+			buffs = append(buffs, ActiveBuff{
+				Unit:       id,
+				SpellName:  spellName,
+				Since:      interval.Start,
+				Stacks:     interval.Stacks,
+				WorldBuff:  IsWorldBuff(spellName),
+				Consumable: consumable,
+			})
+		}
+	}
+
+	slices.SortFunc(buffs, func(a, b ActiveBuff) int {
+		if c := cmp.Compare(a.Unit, b.Unit); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.SpellName, b.SpellName)
+	})
+	return buffs
+}
+
+// WorldBuffs returns the world buffs active on each player at the start of the
+// fight.
+func (f *Fight) WorldBuffs() map[guid.GUID][]ActiveBuff {
+	wbs := make(map[guid.GUID][]ActiveBuff)
+	for _, buff := range f.StartBuffs {
+		if buff.WorldBuff {
+			wbs[buff.Unit] = append(wbs[buff.Unit], buff)
+		}
+	}
+	return wbs
+}
+
+// DroppedBuffs returns the buffs that were active at the start of the fight,
+// but not at the end. Buffs lost on death are included.
+func (f *Fight) DroppedBuffs() []ActiveBuff {
+	if !f.IsStarted() {
+		return nil
+	}
+	end := f.Start.Date().Add(f.Duration())
+
+	var dropped []ActiveBuff
+	for _, buff := range f.StartBuffs {
+		track, ok := f.s.Auras.Units[buff.Unit][buff.SpellName]
+		if !ok {
+			continue
+		}
+		if _, active := track.ActiveAt(end); !active {
+			dropped = append(dropped, buff)
+		}
+	}
+	return dropped
+}
@@ -0,0 +1,45 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartBuffs(t *testing.T) {
+	t.Parallel()
+
+	gain := func(sec float64, spell string) messages.Aura {
+		return messages.Aura{MessageBase: at(sec), Target: testWarrior, SpellName: spell, Amount: 1, Application: types.AuraApplicationGains}
+	}
+
+	s := newTestState(t)
+	process(t, s,
+		gain(-3600, "Rallying Cry of the Dragonslayer"),
+		gain(-1800, "Songflower Serenade"),
+		gain(-60, "Flask of the Titans"),
+		messages.Aura{MessageBase: at(-30), Target: testWarrior, SpellName: "Songflower Serenade", Application: types.AuraApplicationFades},
+		gain(-10, "Battle Shout"),
+		messages.Aura{MessageBase: at(-5), Target: testBoss, SpellName: "Thorns", Amount: 1, Application: types.AuraApplicationGains},
+		messages.Damage{MessageBase: at(1), Caster: testWarrior, Target: testBoss, Amount: 100, HitType: types.HitTypeHit},
+		messages.Aura{MessageBase: at(5), Target: testWarrior, SpellName: "Battle Shout", Application: types.AuraApplicationFades},
+		messages.Damage{MessageBase: at(10), Caster: testWarrior, Target: testBoss, Amount: 100, HitType: types.HitTypeHit},
+	)
+
+	fight := s.Fights.CurrentFight
+	require.Len(t, fight.StartBuffs, 3)
+	for _, buff := range fight.StartBuffs {
+		require.Equal(t, testWarrior, buff.Unit)
+	}
+
+	wbs := fight.WorldBuffs()[testWarrior]
+	require.Len(t, wbs, 1)
+	require.Equal(t, "Rallying Cry of the Dragonslayer", wbs[0].SpellName)
+	require.Equal(t, at(-3600).Date(), wbs[0].Since)
+
+	dropped := fight.DroppedBuffs()
+	require.Len(t, dropped, 1)
+	require.Equal(t, "Battle Shout", dropped[0].SpellName)
+}
@@ -0,0 +1,229 @@
+package state
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+)
+
+// CastResult is how a cast ended.
+type CastResult string
+
+const (
+	CastResultPending     CastResult = ""
+	CastResultCompleted   CastResult = "completed"
+	CastResultInterrupted CastResult = "interrupted"
+	CastResultFailed      CastResult = "failed"
+	// CastResultCancelled is a cast that began, but was replaced by another
+	// cast or the caster died before it finished.
+	CastResultCancelled CastResult = "cancelled"
+)
+
+// CastAttempt is a single cast. Casts with a cast time span from the "begins
+// to cast" line until they complete, fail or are interrupted. Instant casts
+// start and end at the same time.
+type CastAttempt struct {
+	Caster guid.GUID
+	Target *guid.GUID
+	Spell  types.Spell
+	Start  time.Time
+	End    time.Time
+	Result CastResult
+	// Channel is true for channeled spells. The end of a channel is not in the
+	// logs.
+	Channel bool
+}
+
+// Duration is the cast time. Zero for instant casts.
+func (c *CastAttempt) Duration() time.Duration {
+	if c.End.IsZero() {
+		return 0
+	}
+	return c.End.Sub(c.Start)
+}
+
+// SpellCasts is the count of a single spell cast by a unit.
+type SpellCasts struct {
+	SpellName string
+	Casts     int
+	Failed    int
+	Cancelled int
+	// Interrupted is how often the unit was interrupted casting the spell.
+	Interrupted int
+	// CastTime is the total time spent on completed casts of the spell.
+	CastTime time.Duration
+}
+
+// UnitCasts is every cast of a single unit, oldest first.
+type UnitCasts struct {
+	Timeline []*CastAttempt
+}
+
+// Casts keeps the cast timeline of every unit in a fight.
+type Casts struct {
+	Units map[guid.GUID]*UnitCasts
+
+	// pending is the in progress cast of each unit.
+	pending map[guid.GUID]*CastAttempt
+}
+
+func NewCasts() *Casts {
+	return &Casts{
+		Units:   make(map[guid.GUID]*UnitCasts),
+		pending: make(map[guid.GUID]*CastAttempt),
+	}
+}
+
+func (c *Casts) Process(m messages.Message) {
+	switch typed := m.(type) {
+	case messages.Cast:
+		c.Cast(typed)
+	case messages.Interrupt:
+		if attempt := c.Pending(typed.Target); attempt != nil && attempt.Spell.Name == typed.SpellName {
+			c.finish(typed.Target, typed.Date(), CastResultInterrupted)
+		}
+	case messages.Slain:
+		c.finish(typed.Victim, typed.Date(), CastResultCancelled)
+	}
+}
+
+func (c *Casts) Cast(m messages.Cast) {
+	caster := m.Caster.Gid
+	pending := c.Pending(caster)
+
+	switch m.Action {
+	case types.CastActionsBeginsToCast:
+		// Starting a new cast cancels the previous one
+		c.finish(caster, m.Date(), CastResultCancelled)
+		attempt := c.add(m)
+		c.pending[caster] = attempt
+	case types.CastActionsCasts:
+		if pending != nil && pending.Spell.Name == m.Spell.Name {
+			c.finish(caster, m.Date(), CastResultCompleted)
+			return
+		}
+		attempt := c.add(m)
+		attempt.End = m.Date()
+		attempt.Result = CastResultCompleted
+	case types.CastActionsChannels:
+		attempt := c.add(m)
+		attempt.Channel = true
+		attempt.Result = CastResultCompleted
+	case types.CastActionsFailsCasting:
+		if pending != nil && pending.Spell.Name == m.Spell.Name {
+			c.finish(caster, m.Date(), CastResultFailed)
+			return
+		}
+		attempt := c.add(m)
+		attempt.End = m.Date()
+		attempt.Result = CastResultFailed
+	}
+}
+
+// Pending returns the in progress cast of the unit, or nil.
+func (c *Casts) Pending(caster guid.GUID) *CastAttempt {
+	return c.pending[caster]
+}
+
+func (c *Casts) add(m messages.Cast) *CastAttempt {
+	caster := m.Caster.Gid
+	attempt := &CastAttempt{
+		Caster: caster,
+		Spell:  m.Spell,
+		Start:  m.Date(),
+	}
+	if m.Target != nil {
+		target := m.Target.Gid
+		attempt.Target = &target
+	}
+
+	unit, ok := c.Units[caster]
+	if !ok {
+		unit = &UnitCasts{}
+		c.Units[caster] = unit
+	}
+	unit.Timeline = append(unit.Timeline, attempt)
+	return attempt
+}
+
+func (c *Casts) finish(caster guid.GUID, ts time.Time, result CastResult) {
+	attempt, ok := c.pending[caster]
+	if !ok {
+		return
+	}
+	attempt.End = ts
+	attempt.Result = result
+	delete(c.pending, caster)
+}
+
+// Between returns the casts that started between start and end.
+func (u *UnitCasts) Between(start, end time.Time) []*CastAttempt {
+	var casts []*CastAttempt
+	for _, attempt := range u.Timeline {
+		if attempt.Start.Before(start) || attempt.Start.After(end) {
+			continue
+		}
+		casts = append(casts, attempt)
+	}
+	return casts
+}
+
+// Completed returns the number of completed casts between start and end.
+func (u *UnitCasts) Completed(start, end time.Time) int {
+	var total int
+	for _, attempt := range u.Between(start, end) {
+		if attempt.Result == CastResultCompleted {
+			total++
+		}
+	}
+	return total
+}
+
+// CPM returns the completed casts per minute between start and end.
+func (u *UnitCasts) CPM(start, end time.Time) float64 {
+	minutes := end.Sub(start).Minutes()
+	if minutes <= 0 {
+		return 0
+	}
+	return float64(u.Completed(start, end)) / minutes
+}
+
+// BySpell returns the per spell counts of casts between start and end, most
+// cast first.
+func (u *UnitCasts) BySpell(start, end time.Time) []SpellCasts {
+	counts := make(map[string]*SpellCasts)
+	for _, attempt := range u.Between(start, end) {
+		sc, ok := counts[attempt.Spell.Name]
+		if !ok {
+			sc = &SpellCasts{SpellName: attempt.Spell.Name}
+			counts[attempt.Spell.Name] = sc
+		}
+		switch attempt.Result {
+		case CastResultCompleted:
+			sc.Casts++
+			sc.CastTime += attempt.Duration()
+		case CastResultFailed:
+			sc.Failed++
+		case CastResultCancelled:
+			sc.Cancelled++
+		case CastResultInterrupted:
+			sc.Interrupted++
+		}
+	}
+
+	spells := make([]SpellCasts, 0, len(counts))
+	for _, sc := range counts {
+		spells = append(spells, *sc)
+	}
+	slices.SortFunc(spells, func(a, b SpellCasts) int {
+		if c := cmp.Compare(b.Casts, a.Casts); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.SpellName, b.SpellName)
+	})
+	return spells
+}
@@ -0,0 +1,61 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/castv2"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCasts(t *testing.T) {
+	t.Parallel()
+
+	cast := func(sec float64, action types.CastActions, spell string) messages.Cast {
+		return messages.Cast{MessageBase: at(sec), CastV2: castv2.CastV2{
+			Caster: types.Unit{Gid: testPriest},
+			Action: action,
+			Target: &types.Unit{Gid: testWarrior},
+			Spell:  types.Spell{Name: spell},
+		}}
+	}
+
+	casts := NewCasts()
+	for _, m := range []messages.Message{
+		cast(0, types.CastActionsBeginsToCast, "Greater Heal"),
+		cast(3, types.CastActionsCasts, "Greater Heal"),
+		cast(3.5, types.CastActionsCasts, "Power Word: Shield"),
+		// Cancelled by starting another cast
+		cast(4, types.CastActionsBeginsToCast, "Greater Heal"),
+		cast(5, types.CastActionsBeginsToCast, "Flash Heal"),
+		cast(6, types.CastActionsFailsCasting, "Flash Heal"),
+		cast(7, types.CastActionsBeginsToCast, "Greater Heal"),
+		messages.Interrupt{MessageBase: at(8), Caster: testBoss, Target: testPriest, SpellName: "Greater Heal"},
+	} {
+		casts.Process(m)
+	}
+
+	unit := casts.Units[testPriest]
+	require.Len(t, unit.Timeline, 5)
+	require.Equal(t, 3*time.Second, unit.Timeline[0].Duration())
+	require.Zero(t, unit.Timeline[1].Duration(), "instant")
+	require.Equal(t, CastResultCancelled, unit.Timeline[2].Result)
+	require.Equal(t, CastResultFailed, unit.Timeline[3].Result)
+	require.Equal(t, CastResultInterrupted, unit.Timeline[4].Result)
+	require.Nil(t, casts.Pending(testPriest))
+
+	start, end := at(0).Date(), at(60).Date()
+	require.Equal(t, 2, unit.Completed(start, end))
+	require.InDelta(t, 2.0, unit.CPM(start, end), 0.001)
+
+	spells := unit.BySpell(start, end)
+	require.Equal(t, SpellCasts{
+		SpellName:   "Greater Heal",
+		Casts:       1,
+		Cancelled:   1,
+		Interrupted: 1,
+		CastTime:    3 * time.Second,
+	}, spells[0])
+}
@@ -0,0 +1,231 @@
+package state
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/zone"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/consumables"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+)
+
+// consumableDedupeWindow is how close a cast and an aura gain for the same
+// item have to be to count as one use.
+const consumableDedupeWindow = 2 * time.Second
+
+// ConsumableUse is a single consumable used by a player.
+type ConsumableUse struct {
+	consumables.Consumable
+	At     time.Time
+	Player guid.GUID
+	// Zone is where the player was when they used the consumable.
+	Zone zone.Zone
+	// Active is true for consumable buffs that were applied before the fight
+	// and were still active at the start.
+	Active bool
+}
+
+// ConsumableLog is every consumable used by a player during the log.
+type ConsumableLog struct {
+	Uses []ConsumableUse
+
+	last map[consumableKey]time.Time
+}
+
+type consumableKey struct {
+	player guid.GUID
+	item   string
+}
+
+func NewConsumableLog() *ConsumableLog {
+	return &ConsumableLog{
+		last: make(map[consumableKey]time.Time),
+	}
+}
+
+// Cast detects consumables from CAST lines, by spell ID.
+func (l *ConsumableLog) Cast(c messages.Cast, current zone.Zone) {
+	if c.Action != types.CastActionsCasts || !c.Caster.Gid.IsPlayer() {
+		return
+	}
+
+	item, ok := consumables.ByID(c.Spell.ID)
+	if !ok && c.Spell.ID == 0 {
+		item, ok = consumables.ByName(c.Spell.Name)
+	}
+	if !ok {
+		return
+	}
+	l.record(c.Date(), c.Caster.Gid, item, current)
+}
+
+// Aura detects consumables from aura gains, by name.
+func (l *ConsumableLog) Aura(a messages.Aura, current zone.Zone) {
+	if a.Application != types.AuraApplicationGains || a.Harmful || !a.Target.IsPlayer() {
+		return
+	}
+
+	item, ok := l.byAura(a.Target, a.SpellName, a.Date())
+	if !ok {
+		return
+	}
+	l.record(a.Date(), a.Target, item, current)
+}
+
+// byAura returns the consumable for an aura on the player. Auras do not have a
+// spell ID, and some items share an aura name. Those are told apart by the
+// item the player just cast, otherwise the first item in the table wins.
+func (l *ConsumableLog) byAura(player guid.GUID, spellName string, ts time.Time) (consumables.Consumable, bool) {
+	all := consumables.AllByName(spellName)
+	if len(all) == 0 {
+		return consumables.Consumable{}, false
+	}
+	if len(all) > 1 {
+		for i := len(l.Uses) - 1; i >= 0; i-- {
+			use := l.Uses[i]
+			if ts.Sub(use.At) > consumableDedupeWindow {
+				break
+			}
+			if use.Player == player && use.SpellName == spellName && !use.At.After(ts) {
+				return use.Consumable, true
+			}
+		}
+	}
+	return all[0], true
+}
+
+// record adds the use, unless the same item was just recorded for the player.
+// Most consumables log both a cast and an aura gain.
+func (l *ConsumableLog) record(ts time.Time, player guid.GUID, item consumables.Consumable, current zone.Zone) {
+	key := consumableKey{player: player, item: item.Item}
+	if last, ok := l.last[key]; ok && ts.Sub(last) <= consumableDedupeWindow {
+		return
+	}
+	l.last[key] = ts
+
+	l.Uses = append(l.Uses, ConsumableUse{
+		Consumable: item,
+		At:         ts,
+		Player:     player,
+		Zone:       current,
+	})
+}
+
+// Between returns the uses between start and end.
+func (l *ConsumableLog) Between(start, end time.Time) []ConsumableUse {
+	var uses []ConsumableUse
+	for _, use := range l.Uses {
+		if use.At.Before(start) || use.At.After(end) {
+			continue
+		}
+		uses = append(uses, use)
+	}
+	return uses
+}
+
+// CountByPlayer counts the uses of each item by each player.
+func CountByPlayer(uses []ConsumableUse) map[guid.GUID]map[string]int {
+	counts := make(map[guid.GUID]map[string]int)
+	for _, use := range uses {
+		player, ok := counts[use.Player]
+		if !ok {
+			player = make(map[string]int)
+			counts[use.Player] = player
+		}
+		player[use.Item]++
+	}
+	return counts
+}
+
+// InstanceConsumables is the consumables a single player used in a single
+// raid instance.
+type InstanceConsumables struct {
+	Zone   zone.Zone
+	Player guid.GUID
+	// Items is the number of uses of each item.
+	Items map[string]int
+}
+
+// ByInstance counts the uses of each item by each player in each raid
+// instance. Instances are in the order they were first used in, and players
+// are sorted within an instance.
+func (l *ConsumableLog) ByInstance() []InstanceConsumables {
+	type instanceKey struct {
+		name string
+		id   uint32
+	}
+	type playerKey struct {
+		instance instanceKey
+		player   guid.GUID
+	}
+
+	order := make(map[instanceKey]int)
+	index := make(map[playerKey]int)
+	var out []InstanceConsumables
+	for _, use := range l.Uses {
+		instance := instanceKey{name: use.Zone.Name, id: use.Zone.InstanceID}
+		if _, ok := order[instance]; !ok {
+			order[instance] = len(order)
+		}
+
+		key := playerKey{instance: instance, player: use.Player}
+		i, ok := index[key]
+		if !ok {
+			i = len(out)
+			index[key] = i
+			out = append(out, InstanceConsumables{
+				Zone:   use.Zone,
+				Player: use.Player,
+				Items:  make(map[string]int),
+			})
+		}
+		out[i].Items[use.Item]++
+	}
+
+	slices.SortStableFunc(out, func(a, b InstanceConsumables) int {
+		if c := cmp.Compare(order[instanceKey{a.Zone.Name, a.Zone.InstanceID}], order[instanceKey{b.Zone.Name, b.Zone.InstanceID}]); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Player, b.Player)
+	})
+	return out
+}
+
+// Consumables returns the consumables used during the fight, and the
+// consumable buffs that were already active on the participants at the start.
+func (f *Fight) Consumables() []ConsumableUse {
+	if !f.IsStarted() {
+		return nil
+	}
+	start := f.Start.Date()
+
+	var uses []ConsumableUse
+	for _, buff := range f.StartBuffs {
+		if !buff.Consumable || !buff.Since.Before(start) {
+			continue
+		}
+		if _, ok := f.Lives[buff.Unit]; !ok {
+			continue
+		}
+		item, _ := f.s.Consumables.byAura(buff.Unit, buff.SpellName, buff.Since)
+		uses = append(uses, ConsumableUse{
+			Consumable: item,
+			At:         buff.Since,
+			Player:     buff.Unit,
+			Zone:       f.CurrentZone,
+			Active:     true,
+		})
+	}
+
+	uses = append(uses, f.s.Consumables.Between(start, start.Add(f.Duration()))...)
+	slices.SortFunc(uses, func(a, b ConsumableUse) int {
+		if c := a.At.Compare(b.At); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Player, b.Player)
+	})
+	return uses
+}
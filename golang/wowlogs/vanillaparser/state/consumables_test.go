@@ -0,0 +1,113 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/castv2"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/zone"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumables(t *testing.T) {
+	t.Parallel()
+
+	s := newTestState(t)
+	process(t, s,
+		// Flask before the pull
+		messages.Aura{MessageBase: at(-600), Target: testWarrior, SpellName: "Flask of the Titans", Application: types.AuraApplicationGains},
+		messages.Damage{MessageBase: at(1), Caster: testWarrior, Target: testBoss, Amount: 100, HitType: types.HitTypeHit},
+		// Potion logs both a cast and an aura
+		messages.Cast{MessageBase: at(2), CastV2: castv2.CastV2{
+			Caster: types.Unit{Gid: testWarrior},
+			Action: types.CastActionsCasts,
+			Spell:  types.Spell{Name: "Mighty Rage", ID: 17528},
+		}},
+		messages.Aura{MessageBase: at(2), Target: testWarrior, SpellName: "Mighty Rage", Application: types.AuraApplicationGains},
+		// Enemy auras are never consumables
+		messages.Aura{MessageBase: at(3), Target: testBoss, SpellName: "Juju Power", Application: types.AuraApplicationGains},
+		messages.Damage{MessageBase: at(4), Caster: testWarrior, Target: testBoss, Amount: 100, HitType: types.HitTypeHit},
+	)
+
+	require.Len(t, s.Consumables.Uses, 2, "raid log")
+
+	uses := s.Fights.CurrentFight.Consumables()
+	require.Len(t, uses, 2)
+	require.Equal(t, "Flask of the Titans", uses[0].Item)
+	require.True(t, uses[0].Active)
+	require.Equal(t, "Mighty Rage Potion", uses[1].Item)
+	require.False(t, uses[1].Active)
+
+	counts := CountByPlayer(uses)
+	require.Equal(t, map[string]int{"Flask of the Titans": 1, "Mighty Rage Potion": 1}, counts[testWarrior])
+}
+
+func TestConsumablesSharedAura(t *testing.T) {
+	t.Parallel()
+
+	// Mageblood Potion and Nightfin Soup both apply "Mana Regeneration"
+	manaRegen := func(sec float64) messages.Aura {
+		return messages.Aura{MessageBase: at(sec), Target: testPriest, SpellName: "Mana Regeneration", Application: types.AuraApplicationGains}
+	}
+
+	s := newTestState(t)
+	process(t, s,
+		messages.Cast{MessageBase: at(1), CastV2: castv2.CastV2{
+			Caster: types.Unit{Gid: testPriest},
+			Action: types.CastActionsCasts,
+			Spell:  types.Spell{Name: "Mana Regeneration", ID: 18194},
+		}},
+		manaRegen(1),
+		// Without a cast the first item in the table wins
+		manaRegen(60),
+	)
+
+	require.Len(t, s.Consumables.Uses, 2)
+	require.Equal(t, "Nightfin Soup", s.Consumables.Uses[0].Item)
+	require.Equal(t, "Mageblood Potion", s.Consumables.Uses[1].Item)
+}
+
+func TestConsumablesByInstance(t *testing.T) {
+	t.Parallel()
+
+	moltenCore := zone.Zone{Name: "Molten Core", InstanceID: 1}
+	onyxia := zone.Zone{Name: "Onyxia's Lair", InstanceID: 2}
+	flask := func(sec float64, player guid.GUID) messages.Aura {
+		return messages.Aura{MessageBase: at(sec), Target: player, SpellName: "Flask of the Titans", Application: types.AuraApplicationGains}
+	}
+	rage := func(sec float64) messages.Cast {
+		return messages.Cast{MessageBase: at(sec), CastV2: castv2.CastV2{
+			Caster: types.Unit{Gid: testWarrior},
+			Action: types.CastActionsCasts,
+			Spell:  types.Spell{Name: "Mighty Rage", ID: 17528},
+		}}
+	}
+
+	s := newTestState(t)
+	process(t, s,
+		messages.Zone{MessageBase: at(0), Zone: moltenCore},
+		flask(1, testWarrior),
+		flask(2, testPriest),
+		rage(10),
+		rage(100),
+		messages.Zone{MessageBase: at(1000), Zone: onyxia},
+		rage(1010),
+		// The same instance again after leaving it
+		messages.Zone{MessageBase: at(2000), Zone: moltenCore},
+		rage(2010),
+	)
+
+	require.Equal(t, []InstanceConsumables{
+		{Zone: moltenCore, Player: testPriest, Items: map[string]int{"Flask of the Titans": 1}},
+		{Zone: moltenCore, Player: testWarrior, Items: map[string]int{"Flask of the Titans": 1, "Mighty Rage Potion": 3}},
+		{Zone: onyxia, Player: testWarrior, Items: map[string]int{"Mighty Rage Potion": 1}},
+	}, s.Consumables.ByInstance())
+
+	summary := s.ConsumablesSummary()
+	require.Contains(t, summary, "Molten Core (Instance 1)")
+	require.Contains(t, summary, "Tankman")
+	require.Contains(t, summary, "Flask of the Titans x1, Mighty Rage Potion x3")
+	require.Contains(t, summary, "Onyxia's Lair (Instance 2)")
+}
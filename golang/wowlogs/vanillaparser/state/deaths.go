@@ -0,0 +1,104 @@
+package state
+
+import (
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+)
+
+const (
+	DefaultRecapEvents = 15
+	DefaultRecapWindow = 10 * time.Second
+)
+
+// History keeps the most recent damage, heal and aura events that landed on
+// each unit. It is used to build a recap when a unit dies.
+type History struct {
+	// MaxEvents is the most events kept per unit.
+	MaxEvents int
+	// Window is how far back before a death events are included in a recap.
+	Window time.Duration
+
+	units map[guid.GUID][]messages.Message
+}
+
+// DeathRecap explains a single unit death.
+type DeathRecap struct {
+	Victim guid.GUID
+	// Killer is from the slain message if present, otherwise the caster of
+	// the killing blow.
+	Killer *guid.GUID
+	Slain  messages.Slain
+	// Events are the last events on the victim before the death, oldest first.
+	Events []messages.Message
+	// KillingBlow is the last event that did damage to the victim. Either a
+	// messages.Damage or messages.FallDamage.
+	// Vanilla logs do not include overkill or remaining health, so the
+	// overkill cannot be inferred from the killing blow.
+	KillingBlow messages.Message
+}
+
+func NewHistory() *History {
+	return &History{
+		MaxEvents: DefaultRecapEvents,
+		Window:    DefaultRecapWindow,
+		units:     make(map[guid.GUID][]messages.Message),
+	}
+}
+
+// Record adds an event that landed on the target.
+func (h *History) Record(target guid.GUID, m messages.Message) {
+	events := append(h.units[target], m)
+	if h.MaxEvents > 0 && len(events) > h.MaxEvents {
+		events = events[len(events)-h.MaxEvents:]
+	}
+	h.units[target] = events
+}
+
+// Recent returns the recorded events on the target within the window before ts.
+func (h *History) Recent(target guid.GUID, ts time.Time) []messages.Message {
+	events := h.units[target]
+	for i, m := range events {
+		if h.Window <= 0 || ts.Sub(m.Date()) <= h.Window {
+			return append([]messages.Message{}, events[i:]...)
+		}
+	}
+	return nil
+}
+
+// Clear removes all events for the target.
+func (h *History) Clear(target guid.GUID) {
+	delete(h.units, target)
+}
+
+// Recap builds the death recap for the slain unit.
+func (h *History) Recap(slain messages.Slain) DeathRecap {
+	recap := DeathRecap{
+		Victim: slain.Victim,
+		Killer: slain.Killer,
+		Slain:  slain,
+		Events: h.Recent(slain.Victim, slain.Date()),
+	}
+
+KillingBlow:
+	for i := len(recap.Events) - 1; i >= 0; i-- {
+		switch typed := recap.Events[i].(type) {
+		case messages.Damage:
+			if typed.Amount <= 0 {
+				continue
+			}
+			recap.KillingBlow = typed
+			if recap.Killer == nil {
+				killer := typed.Caster
+				recap.Killer = &killer
+			}
+			break KillingBlow
+		case messages.FallDamage:
+			recap.KillingBlow = typed
+			break KillingBlow
+		}
+	}
+
+	return recap
+}
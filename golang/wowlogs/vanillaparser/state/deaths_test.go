@@ -0,0 +1,64 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/internal/ptr"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeathRecap(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Window", func(t *testing.T) {
+		t.Parallel()
+
+		h := NewHistory()
+		h.MaxEvents = 3
+		h.Window = 5 * time.Second
+
+		old := messages.Heal{MessageBase: at(0), Caster: testPriest, Target: testWarrior, SpellName: "Renew", Amount: 100}
+		hit := messages.Damage{MessageBase: at(6), Caster: testBoss, Target: testWarrior, Amount: 900, HitType: types.HitTypeHit}
+		miss := messages.Damage{MessageBase: at(7), Caster: testBoss, Target: testWarrior, HitType: types.HitTypeMiss}
+		h.Record(testWarrior, old)
+		h.Record(testWarrior, hit)
+		h.Record(testWarrior, miss)
+
+		recap := h.Recap(messages.Slain{MessageBase: at(8), Victim: testWarrior})
+		require.Equal(t, []messages.Message{hit, miss}, recap.Events)
+		require.Equal(t, hit, recap.KillingBlow)
+		require.Equal(t, ptr.Ref(testBoss), recap.Killer)
+
+		// Max events drops the oldest
+		h.Record(testWarrior, hit)
+		h.Record(testWarrior, miss)
+		require.Len(t, h.units[testWarrior], 3)
+	})
+
+	t.Run("Fight", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestState(t)
+		fall := messages.FallDamage{MessageBase: at(3), Target: testPriest, Amount: 2000}
+		process(t, s,
+			messages.Damage{MessageBase: at(1), Caster: testWarrior, Target: testBoss, Amount: 100, HitType: types.HitTypeHit},
+			messages.Heal{MessageBase: at(2), Caster: testPriest, Target: testPriest, SpellName: "Renew", Amount: 100, HitType: types.HitTypeHit},
+			fall,
+			messages.Slain{MessageBase: at(3), Victim: testPriest},
+		)
+
+		fight := s.Fights.Fights[0]
+		require.Len(t, fight.Deaths, 1)
+		death := fight.Deaths[0]
+		require.Equal(t, testPriest, death.Victim)
+		require.Nil(t, death.Killer)
+		require.Equal(t, fall, death.KillingBlow)
+		require.Len(t, death.Events, 2)
+
+		// History is cleared after death
+		require.Empty(t, s.History.Recent(testPriest, at(3).Date()))
+	})
+}
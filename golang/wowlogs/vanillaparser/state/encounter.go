@@ -0,0 +1,130 @@
+package state
+
+import (
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/encounters"
+)
+
+// Outcome is how a fight ended.
+type Outcome string
+
+const (
+	// OutcomeUnknown is used for fights that have not ended.
+	OutcomeUnknown Outcome = ""
+	// OutcomeKill is every boss of the encounter dying. For fights without an
+	// encounter, it is every hostile unit dying.
+	OutcomeKill Outcome = "kill"
+	// OutcomeWipe is most of the players dying.
+	OutcomeWipe Outcome = "wipe"
+	// OutcomeReset is the fight ending without a kill or a wipe. Like the raid
+	// running out, or the enemies evading.
+	OutcomeReset Outcome = "reset"
+)
+
+// detectEncounter labels the fight with the encounter the unit belongs to.
+// The first boss unit seen decides the encounter.
+func (f *Fight) detectEncounter(id guid.GUID) {
+	if f.Encounter != nil {
+		return
+	}
+	enc, ok := encounters.Lookup(id)
+	if !ok {
+		return
+	}
+	f.Encounter = &enc
+}
+
+// isBoss returns true if the unit is one of the bosses of the encounter.
+func (f *Fight) isBoss(id guid.GUID) bool {
+	if f.Encounter == nil {
+		return false
+	}
+	entry, ok := id.GetEntry()
+	return ok && f.Encounter.IsBoss(entry)
+}
+
+// trackBoss records a boss unit seen in the fight. Encounters like Majordomo
+// spawn several units of the same entry, and every one of them must die if the
+// encounter sets KillEveryUnit.
+func (f *Fight) trackBoss(id guid.GUID) {
+	if !f.isBoss(id) {
+		return
+	}
+	if f.bossUnits == nil {
+		f.bossUnits = make(map[guid.GUID]bool)
+	}
+	if _, ok := f.bossUnits[id]; !ok {
+		f.bossUnits[id] = false
+	}
+}
+
+// bossSlain records the death of an encounter boss. Returns true if every boss
+// of the encounter has died.
+func (f *Fight) bossSlain(id guid.GUID) bool {
+	if !f.isBoss(id) {
+		return false
+	}
+
+	f.trackBoss(id)
+	f.bossUnits[id] = true
+	return f.EncounterKilled()
+}
+
+// EncounterKilled returns true if every boss of the encounter has died. Each
+// boss entry must have been seen, and any unit of the entry must have died. If
+// the encounter sets KillEveryUnit, every unit of the entry seen in the fight
+// must have died.
+func (f *Fight) EncounterKilled() bool {
+	if f.Encounter == nil {
+		return false
+	}
+	for _, boss := range f.Encounter.Bosses {
+		killed := false
+		for id, dead := range f.bossUnits {
+			if entry, _ := id.GetEntry(); entry != boss {
+				continue
+			}
+			if !dead && f.Encounter.KillEveryUnit {
+				return false
+			}
+			killed = killed || dead
+		}
+		if !killed {
+			return false
+		}
+	}
+	return true
+}
+
+// classify decides the outcome of the fight. Only called when the fight ends.
+func (f *Fight) classify() Outcome {
+	if f.Encounter != nil {
+		if f.EncounterKilled() {
+			return OutcomeKill
+		}
+	} else {
+		remaining := f.RemainingUnits()
+		if remaining.HostileActive == 0 && remaining.HostileInactive > 0 {
+			return OutcomeKill
+		}
+	}
+
+	// A wipe is at least half of the players in the fight dying. Some players
+	// survive wipes with feign death, soulstones or running out.
+	var alive, dead int
+	for id, lives := range f.Lives {
+		if !id.IsPlayer() || len(lives.Alive) == 0 {
+			continue
+		}
+		if lives.IsActive() {
+			alive++
+		} else {
+			dead++
+		}
+	}
+	if dead > 0 && dead*2 >= alive+dead {
+		return OutcomeWipe
+	}
+
+	return OutcomeReset
+}
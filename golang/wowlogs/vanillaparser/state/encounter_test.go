@@ -0,0 +1,143 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/Emyrk/chronicle/golang/internal/ptr"
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/unitinfo"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func creature(entry uint32, low uint64) guid.GUID {
+	return guid.GUID(0xF130000000000000 | uint64(entry)<<24 | low)
+}
+
+func TestEncounterOutcome(t *testing.T) {
+	t.Parallel()
+
+	veklor := creature(15276, 1)
+	veknilash := creature(15275, 2)
+
+	hit := func(sec float64, caster, target guid.GUID) messages.Damage {
+		return messages.Damage{MessageBase: at(sec), Caster: caster, Target: target, Amount: 100, HitType: types.HitTypeHit}
+	}
+
+	t.Run("Kill", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestState(t)
+		for _, info := range []unitinfo.Info{
+			{Guid: veklor, Name: "Emperor Vek'lor"},
+			{Guid: veknilash, Name: "Emperor Vek'nilash"},
+		} {
+			process(t, s, messages.Unit{MessageBase: at(0), Info: info})
+		}
+
+		process(t, s,
+			hit(1, testWarrior, veklor),
+			hit(1, testWarrior, veknilash),
+			hit(2, veklor, testWarrior),
+			hit(2, veknilash, testWarrior),
+			messages.Slain{MessageBase: at(3), Victim: veklor, Killer: ptr.Ref(testWarrior)},
+		)
+
+		fight := s.Fights.Fights[0]
+		require.NotNil(t, fight.Encounter)
+		require.Equal(t, "Twin Emperors", fight.Encounter.Name)
+		require.False(t, fight.IsDone(), "one emperor is still alive")
+
+		process(t, s, messages.Slain{MessageBase: at(4), Victim: veknilash, Killer: ptr.Ref(testWarrior)})
+		require.True(t, fight.IsDone())
+		require.Equal(t, OutcomeKill, fight.Outcome)
+	})
+
+	t.Run("EveryAdd", func(t *testing.T) {
+		t.Parallel()
+
+		// Majordomo is won by killing 4 Flamewaker Healers and 4 Flamewaker
+		// Elites, there is more than one unit of each boss entry.
+		var adds []guid.GUID
+		for i := range uint64(4) {
+			adds = append(adds, creature(11663, 10+i), creature(11664, 20+i))
+		}
+
+		s := newTestState(t)
+		for _, add := range adds {
+			process(t, s,
+				messages.Unit{MessageBase: at(0), Info: unitinfo.Info{Guid: add, Name: "Flamewaker"}},
+				hit(1, testWarrior, add),
+				hit(2, add, testWarrior),
+			)
+		}
+
+		fight := s.Fights.Fights[0]
+		require.NotNil(t, fight.Encounter)
+		require.Equal(t, "Majordomo Executus", fight.Encounter.Name)
+
+		// One healer and one elite
+		process(t, s,
+			messages.Slain{MessageBase: at(3), Victim: adds[0], Killer: ptr.Ref(testWarrior)},
+			messages.Slain{MessageBase: at(4), Victim: adds[1], Killer: ptr.Ref(testWarrior)},
+		)
+		require.False(t, fight.IsDone(), "3 healers and 3 elites are still alive")
+		require.False(t, fight.EncounterKilled())
+
+		for i, add := range adds[2:] {
+			require.False(t, fight.IsDone())
+			process(t, s, messages.Slain{MessageBase: at(5 + float64(i)), Victim: add, Killer: ptr.Ref(testWarrior)})
+		}
+		require.True(t, fight.IsDone())
+		require.Equal(t, EndReasonKill, fight.EndReason)
+		require.Equal(t, OutcomeKill, fight.Outcome)
+	})
+
+	t.Run("AnyUnit", func(t *testing.T) {
+		t.Parallel()
+
+		// Skeram's images share his entry, and despawn when he dies.
+		skeram := creature(15263, 1)
+		images := []guid.GUID{creature(15263, 2), creature(15263, 3)}
+
+		s := newTestState(t)
+		for _, unit := range append([]guid.GUID{skeram}, images...) {
+			process(t, s,
+				messages.Unit{MessageBase: at(0), Info: unitinfo.Info{Guid: unit, Name: "The Prophet Skeram"}},
+				hit(1, testWarrior, unit),
+				hit(2, unit, testWarrior),
+			)
+		}
+
+		fight := s.Fights.Fights[0]
+		require.NotNil(t, fight.Encounter)
+		require.Equal(t, "The Prophet Skeram", fight.Encounter.Name)
+
+		process(t, s, messages.Slain{MessageBase: at(3), Victim: skeram, Killer: ptr.Ref(testWarrior)})
+		require.True(t, fight.EncounterKilled(), "the images never die")
+		require.True(t, fight.IsDone())
+		require.Equal(t, EndReasonKill, fight.EndReason)
+		require.Equal(t, OutcomeKill, fight.Outcome)
+	})
+
+	t.Run("Wipe", func(t *testing.T) {
+		t.Parallel()
+
+		s := newTestState(t)
+		process(t, s,
+			hit(1, testWarrior, testBoss),
+			hit(2, testBoss, testWarrior),
+			messages.Heal{MessageBase: at(2), Caster: testPriest, Target: testWarrior, SpellName: "Heal", Amount: 100, HitType: types.HitTypeHit},
+			messages.Slain{MessageBase: at(3), Victim: testWarrior, Killer: ptr.Ref(testBoss)},
+			messages.Slain{MessageBase: at(4), Victim: testPriest, Killer: ptr.Ref(testBoss)},
+		)
+		fight := s.Fights.Fights[0]
+		require.Nil(t, fight.Encounter)
+		require.False(t, fight.IsDone())
+
+		// Nothing else happens, the fight ends some other way
+		fight.EndFight(at(30), EndReasonZone)
+		require.Equal(t, OutcomeWipe, fight.Outcome)
+	})
+}
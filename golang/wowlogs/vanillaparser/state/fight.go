@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"strings"
 	"time"
 
@@ -11,13 +13,22 @@ import (
 	"github.com/Emyrk/chronicle/golang/wowlogs/types"
 	"github.com/Emyrk/chronicle/golang/wowlogs/types/unitinfo"
 	"github.com/Emyrk/chronicle/golang/wowlogs/types/zone"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/encounters"
 	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
 )
 
+// DefaultCombatTimeout is how long a fight can go without any damage or heals
+// before it is ended.
+const DefaultCombatTimeout = 30 * time.Second
+
 type Fights struct {
 	Logger *slog.Logger
 	s      *State
 
+	// Timeout ends the current fight if there is no damage or heal activity
+	// for this long. A zero value disables the timeout.
+	Timeout time.Duration
+
 	Fights       []*Fight
 	CurrentFight *Fight
 }
@@ -27,6 +38,7 @@ func NewFights(s *State) *Fights {
 	return &Fights{
 		Logger:       s.logger,
 		s:            s,
+		Timeout:      DefaultCombatTimeout,
 		CurrentFight: current,
 		Fights: []*Fight{
 			current,
@@ -35,25 +47,36 @@ func NewFights(s *State) *Fights {
 }
 
 func (fs *Fights) Process(m messages.Message) error {
+	// Evades and resets never kill all the hostiles, so the fight has to be
+	// ended by the lack of activity. The end is the last activity, so the idle
+	// time is not included in the fight duration.
+	if fs.CurrentFight.TimedOut(m.Date(), fs.Timeout) {
+		fs.CurrentFight.EndFight(fs.CurrentFight.lastActivity, EndReasonTimeout)
+		fs.next()
+	}
+
 	err := fs.CurrentFight.Process(m)
 	if err != nil {
 		return err
 	}
 
-	// TODO: Timeout
-
-	// Always have a fight started. The start time will start on the first
-	// damage. But start collecting participants & units right away.
 	if fs.CurrentFight.IsDone() {
-		last := fs.CurrentFight
-		fs.CurrentFight = NewFight(fs.s)
-		fs.CurrentFight.PreviousFight = last
-		fs.Fights = append(fs.Fights, fs.CurrentFight)
+		fs.next()
 	}
 
 	return nil
 }
 
+// next starts a new fight. Always have a fight started. The start time will
+// start on the first damage. But start collecting participants & units right
+// away.
+func (fs *Fights) next() {
+	last := fs.CurrentFight
+	fs.CurrentFight = NewFight(fs.s)
+	fs.CurrentFight.PreviousFight = last
+	fs.Fights = append(fs.Fights, fs.CurrentFight)
+}
+
 type Fight struct {
 	Logger        *slog.Logger
 	s             *State // Reference to parent state
@@ -62,21 +85,73 @@ type Fight struct {
 	// Lives keeps track of the life spans of units during the fight.
 	// A unit can be revived during a fight, so multiple lives are possible.
 	// TODO: portals too maybe? Like hearth
-	Lives map[guid.GUID]Lives
+	Lives map[guid.GUID]*Lives
 
 	CurrentZone zone.Zone
 
+	// DamageMeter aggregates all damage done and taken after the fight started.
+	DamageMeter *Meter
+	// HealingMeter aggregates all healing done and received after the fight started.
+	HealingMeter *Meter
+	// Resources tracks mana, rage, energy and other resource changes after the
+	// fight started.
+	Resources *Resources
+	// Threat estimates the threat table of every enemy in the fight.
+	Threat *Threat
+	// Casts is the cast timeline of every unit.
+	Casts *Casts
+	// Interrupts tracks interrupts, dispels and casts that were not interrupted.
+	Interrupts *Interrupts
+	// StartBuffs are the buffs every friendly player had when the fight started.
+	StartBuffs []ActiveBuff
+	// Deaths has a recap for every unit that died during the fight.
+	Deaths []DeathRecap
+
+	// Encounter is the boss encounter, if any boss was part of the fight.
+	Encounter *encounters.Encounter
+	// Outcome is set when the fight ends.
+	Outcome Outcome
+	// bossUnits are the boss units seen in the fight, and if they died.
+	bossUnits map[guid.GUID]bool
+
+	// EndReason is why the fight ended.
+	EndReason EndReason
+
 	// Start & End of the fight
 	Start messages.Message
 	End   messages.Message
+	// latest is the timestamp of the most recent message processed.
+	latest time.Time
+	// lastActivity is the most recent damage or heal message.
+	lastActivity messages.Message
 }
 
+// EndReason is what caused a fight to end.
+type EndReason string
+
+const (
+	EndReasonNone EndReason = ""
+	// EndReasonZone is the player changing zones.
+	EndReasonZone EndReason = "zone"
+	// EndReasonKill is all hostiles or all encounter bosses dying.
+	EndReasonKill EndReason = "kill"
+	// EndReasonTimeout is no damage or heal activity for the combat timeout.
+	EndReasonTimeout EndReason = "timeout"
+)
+
 func NewFight(s *State) *Fight {
+	casts := NewCasts()
 	return &Fight{
-		Logger:      s.logger,
-		s:           s,
-		Lives:       make(map[guid.GUID]Lives),
-		CurrentZone: s.CurrentZone,
+		Logger:       s.logger,
+		s:            s,
+		Lives:        make(map[guid.GUID]*Lives),
+		CurrentZone:  s.CurrentZone,
+		DamageMeter:  NewMeter(),
+		HealingMeter: NewMeter(),
+		Resources:    NewResources(),
+		Threat:       NewThreat(s),
+		Casts:        casts,
+		Interrupts:   NewInterrupts(s, casts),
 	}
 }
 
@@ -84,19 +159,20 @@ func (f *Fight) Process(m messages.Message) error {
 	if f.IsDone() {
 		return errors.New("fight is already done")
 	}
+	f.latest = m.Date()
 
 	var err error
 	switch typed := m.(type) {
 	case messages.Zone:
 		f.Zone(typed)
 	case messages.Damage:
-		f.Damage(typed)
+		err = f.Damage(typed)
 	case messages.FallDamage:
 		//f.FallDamage(typed)
 	case messages.Cast:
 		err = f.CastV2(typed)
 	case messages.Heal:
-		f.Heal(typed)
+		err = f.Heal(typed)
 	case messages.Combatant:
 		//f.Combatant(typed)
 	case messages.Unit:
@@ -108,6 +184,16 @@ func (f *Fight) Process(m messages.Message) error {
 		return err
 	}
 
+	// Casts begin before the pull, so casts and interrupts are always tracked.
+	f.Interrupts.Process(m)
+	f.Casts.Process(m)
+	if f.IsStarted() {
+		f.Threat.Process(m)
+		if rc, ok := m.(messages.ResourceChange); ok {
+			f.Resources.Process(rc)
+		}
+	}
+
 	return nil
 }
 
@@ -116,13 +202,14 @@ func (f *Fight) StartFight(msg messages.Message) {
 		return // Fight already started
 	}
 	f.Start = msg
+	f.StartBuffs = f.s.snapshotBuffs(msg.Date())
 	f.Logger.Info("fight started",
 		slog.Time("date", msg.Date()),
 		slog.String("zone", f.CurrentZone.Name),
 	)
 }
 
-func (f *Fight) EndFight(msg messages.Message) {
+func (f *Fight) EndFight(msg messages.Message, reason EndReason) {
 	if f.Start == nil {
 		f.Logger.Warn("attempted to end fight that hasn't started")
 		return
@@ -132,6 +219,8 @@ func (f *Fight) EndFight(msg messages.Message) {
 	}
 
 	f.End = msg
+	f.EndReason = reason
+	f.Outcome = f.classify()
 	var dur time.Duration
 	if f.IsStarted() {
 		dur = msg.Date().Sub(f.Start.Date())
@@ -141,9 +230,20 @@ func (f *Fight) EndFight(msg messages.Message) {
 		slog.Duration("duration", dur),
 		slog.Time("date", msg.Date()),
 		slog.String("zone", f.CurrentZone.Name),
+		slog.String("encounter", f.EncounterName()),
+		slog.String("outcome", string(f.Outcome)),
+		slog.String("reason", string(f.EndReason)),
 	)
 }
 
+// EncounterName returns the name of the boss encounter, or an empty string.
+func (f *Fight) EncounterName() string {
+	if f.Encounter == nil {
+		return ""
+	}
+	return f.Encounter.Name
+}
+
 func (f *Fight) IsDone() bool {
 	return f.End != nil && f.Start != nil
 }
@@ -152,6 +252,61 @@ func (f *Fight) IsStarted() bool {
 	return f.Start != nil
 }
 
+// TimedOut returns true if the fight is in progress and there has been no
+// damage or heal activity for longer than the timeout.
+func (f *Fight) TimedOut(now time.Time, timeout time.Duration) bool {
+	if timeout <= 0 || !f.IsStarted() || f.IsDone() || f.lastActivity == nil {
+		return false
+	}
+	return now.Sub(f.lastActivity.Date()) > timeout
+}
+
+// Duration is the time between the start and end of the fight. If the fight
+// is still in progress, the most recent message is used as the end.
+func (f *Fight) Duration() time.Duration {
+	if !f.IsStarted() {
+		return 0
+	}
+	if f.End != nil {
+		return f.End.Date().Sub(f.Start.Date())
+	}
+	return f.latest.Sub(f.Start.Date())
+}
+
+// DPS returns the damage per second done by the unit over the fight duration.
+func (f *Fight) DPS(id guid.GUID) float64 {
+	return f.DamageMeter.PerSecond(id, f.Duration())
+}
+
+// DamageByOwner returns the damage meter with pets, totems and guardians
+// folded into their owners. DamageMeter keeps them separate.
+func (f *Fight) DamageByOwner() *Meter {
+	return f.DamageMeter.Fold(f.s.Owners.Resolve)
+}
+
+// HealingByOwner returns the healing meter with pets, totems and guardians
+// folded into their owners. HealingMeter keeps them separate.
+func (f *Fight) HealingByOwner() *Meter {
+	return f.HealingMeter.Fold(f.s.Owners.Resolve)
+}
+
+// AuraUptimes returns the buff and debuff uptimes of all units that
+// participated in the fight.
+func (f *Fight) AuraUptimes() []AuraUptime {
+	if !f.IsStarted() {
+		return nil
+	}
+	return f.s.Auras.Uptimes(f.Start.Date(), f.Start.Date().Add(f.Duration()), func(id guid.GUID) bool {
+		_, ok := f.Lives[id]
+		return ok
+	})
+}
+
+// HPS returns the healing per second done by the unit over the fight duration.
+func (f *Fight) HPS(id guid.GUID) float64 {
+	return f.HealingMeter.PerSecond(id, f.Duration())
+}
+
 func (f *Fight) Zone(m messages.Zone) {
 	if m.Zone.Equal(f.CurrentZone) {
 		return
@@ -161,7 +316,7 @@ func (f *Fight) Zone(m messages.Zone) {
 		f.StartFight(m)
 	}
 
-	f.EndFight(m)
+	f.EndFight(m, EndReasonZone)
 }
 
 func (f *Fight) Slain(slain messages.Slain) error {
@@ -169,9 +324,16 @@ func (f *Fight) Slain(slain messages.Slain) error {
 		f.BumpUnit(*slain.Killer, slain)
 	}
 
-	_, err := f.UnitLives(slain.Victim, slain)
-	if err != nil {
-		return fmt.Errorf("slain: %w", err)
+	lives := f.BumpUnit(slain.Victim, slain)
+	lives.EndLife(slain)
+
+	f.Deaths = append(f.Deaths, f.s.History.Recap(slain))
+	f.s.History.Clear(slain.Victim)
+
+	// Boss encounters end when all bosses die, even if adds are still alive.
+	if f.bossSlain(slain.Victim) && f.IsStarted() {
+		f.EndFight(slain, EndReasonKill)
+		return nil
 	}
 
 	if f.IsStarted() {
@@ -185,15 +347,34 @@ func (f *Fight) Slain(slain messages.Slain) error {
 			slog.Int("unknown_inactive", remaining.UnknownInactive),
 		)
 		if remaining.HostileActive == 0 && remaining.FriendlyActive != 0 {
-			f.EndFight(slain)
+			f.EndFight(slain, EndReasonKill)
 		}
 	}
 
 	return nil
 }
 
-func (f *Fight) Heal(d messages.Heal) {
+func (f *Fight) Heal(h messages.Heal) error {
+	f.lastActivity = h
+	f.BumpUnit(h.Caster, h)
+	f.BumpUnit(h.Target, h)
 
+	// Caster is alive if they are healing
+	_, err := f.UnitLives(h.Caster, h)
+	if err != nil {
+		return fmt.Errorf("heal: %w", err)
+	}
+
+	if f.IsStarted() {
+		f.HealingMeter.Add(MeterEvent{
+			Caster:  h.Caster,
+			Target:  h.Target,
+			Spell:   h.SpellName,
+			Amount:  h.Amount,
+			HitType: h.HitType,
+		})
+	}
+	return nil
 }
 
 func (f *Fight) CastV2(c messages.Cast) error {
@@ -213,6 +394,7 @@ func (f *Fight) CastV2(c messages.Cast) error {
 }
 
 func (f *Fight) Damage(d messages.Damage) error {
+	f.lastActivity = d
 	f.BumpUnit(d.Caster, d)
 	f.BumpUnit(d.Target, d)
 
@@ -229,7 +411,7 @@ func (f *Fight) Damage(d messages.Damage) error {
 			f.StartFight(d)
 		} else {
 			recentlyInactive := func(id guid.GUID) bool {
-				exists, ok := f.PreviousFight.Lives[d.Caster]
+				exists, ok := f.PreviousFight.Lives[id]
 				if !ok {
 					return false
 				}
@@ -253,13 +435,52 @@ func (f *Fight) Damage(d messages.Damage) error {
 			}
 		}
 	}
+
+	// Damage before the fight starts is DoTs ticking from the previous fight.
+	if f.IsStarted() {
+		f.DamageMeter.Add(MeterEvent{
+			Caster:  d.Caster,
+			Target:  d.Target,
+			Spell:   damageSpellName(d),
+			School:  damageSchool(d),
+			Amount:  d.Amount,
+			HitType: d.HitType,
+		})
+	}
 	return nil
 }
 
+func damageSpellName(d messages.Damage) string {
+	if d.SpellName != nil {
+		return *d.SpellName
+	}
+	if d.HitType.Has(types.HitTypeReflect) {
+		return DamageShieldSpellName
+	}
+	return MeleeSpellName
+}
+
+// damageSchool defaults to physical, as the logs omit the school for
+// physical damage.
+func damageSchool(d messages.Damage) types.School {
+	if d.School == types.None {
+		return types.PhysicalSchool
+	}
+	return d.School
+}
+
 func (f *Fight) getUnit(gid guid.GUID) (unitinfo.Info, bool) {
 	return f.s.Units.Get(gid)
 }
 
+func (f *Fight) getUnitName(gid guid.GUID) string {
+	info, ok := f.getUnit(gid)
+	if !ok || info.Name == "" {
+		return gid.String()
+	}
+	return info.Name
+}
+
 // String returns a summary of the fights
 func (fs *Fights) String() string {
 	var b strings.Builder
@@ -277,24 +498,34 @@ func (fs *Fights) String() string {
 		if fight.IsStarted() && fight.IsDone() {
 			b.WriteString(fmt.Sprintf("\n--- Fight #%d ---\n", i+1))
 			b.WriteString(fight.String())
+
+			drops := fs.s.Loot.ByFight(i)
+			if len(drops) > 0 {
+				b.WriteString(fmt.Sprintf("\nLoot: %d\n", len(drops)))
+				for _, drop := range drops {
+					b.WriteString(fmt.Sprintf("  - %s [%s] x%d (%s)\n", fight.getUnitName(drop.Receiver), drop.ItemName, drop.Count, drop.Quality))
+				}
+			}
 		}
 	}
 
 	return b.String()
 }
 
-func (f *Fight) BumpUnit(id guid.GUID, msg messages.Message) Lives {
+func (f *Fight) BumpUnit(id guid.GUID, msg messages.Message) *Lives {
 	if life, ok := f.Lives[id]; ok {
 		life.Bump(msg)
 		return life
 	}
 
+	f.detectEncounter(id)
+	f.trackBoss(id)
 	life := NewLives(msg)
-	f.Lives[id] = life
-	return life
+	f.Lives[id] = &life
+	return &life
 }
 
-func (f *Fight) UnitLives(id guid.GUID, msg messages.Message) (Lives, error) {
+func (f *Fight) UnitLives(id guid.GUID, msg messages.Message) (*Lives, error) {
 	lives := f.BumpUnit(id, msg)
 	if !lives.IsActive() {
 		err := lives.StartLife(msg)
@@ -363,6 +594,13 @@ func (f *Fight) String() string {
 		b.WriteString("\n")
 	}
 
+	if f.Encounter != nil {
+		b.WriteString(fmt.Sprintf("Encounter: %s (%s)\n", f.Encounter.Name, f.Encounter.Raid))
+	}
+	if f.Outcome != OutcomeUnknown {
+		b.WriteString(fmt.Sprintf("Outcome: %s (%s)\n", f.Outcome, f.EndReason))
+	}
+
 	if f.IsStarted() && f.IsDone() {
 		duration := f.End.Date().Sub(f.Start.Date())
 		b.WriteString(fmt.Sprintf("Duration: %s\n", duration.Round(time.Second)))
@@ -387,47 +625,155 @@ func (f *Fight) String() string {
 		}
 	}
 
-	//// Damage summary
-	//if len(f.DamageDone) > 0 {
-	//	b.WriteString("\nDamage Done:\n")
-	//	type damagePair struct {
-	//		guid   guid.GUID
-	//		amount int64
-	//	}
-	//	var pairs []damagePair
-	//	for g, amt := range f.DamageDone {
-	//		pairs = append(pairs, damagePair{guid: g, amount: amt})
-	//	}
-	//	sort.Slice(pairs, func(i, j int) bool {
-	//		return pairs[i].amount > pairs[j].amount
-	//	})
-	//
-	//	for _, pair := range pairs {
-	//		name := f.getUnitName(pair.guid)
-	//		b.WriteString(fmt.Sprintf("  - %-20s: %10d\n", name, pair.amount))
-	//	}
-	//}
-	//
-	//// Healing summary
-	//if len(f.HealingDone) > 0 {
-	//	b.WriteString("\nHealing Done:\n")
-	//	type healingPair struct {
-	//		guid   guid.GUID
-	//		amount int64
-	//	}
-	//	var pairs []healingPair
-	//	for g, amt := range f.HealingDone {
-	//		pairs = append(pairs, healingPair{guid: g, amount: amt})
-	//	}
-	//	sort.Slice(pairs, func(i, j int) bool {
-	//		return pairs[i].amount > pairs[j].amount
-	//	})
-	//
-	//	for _, pair := range pairs {
-	//		name := f.getUnitName(pair.guid)
-	//		b.WriteString(fmt.Sprintf("  - %-20s: %10d\n", name, pair.amount))
-	//	}
-	//}
+	// Damage summary, pets are included with their owners
+	damage := f.DamageByOwner()
+	if len(damage.Done) > 0 {
+		b.WriteString(fmt.Sprintf("\nDamage Done: %d\n", damage.Total))
+		for _, row := range damage.DoneRanking() {
+			if row.Total == 0 {
+				continue
+			}
+			b.WriteString(fmt.Sprintf("  - %-20s: %10d (%.1f dps)\n", f.getUnitName(row.Unit), row.Total, damage.PerSecond(row.Unit, f.Duration())))
+		}
+	}
+
+	// Healing summary
+	healing := f.HealingByOwner()
+	if len(healing.Done) > 0 {
+		b.WriteString(fmt.Sprintf("\nHealing Done: %d\n", healing.Total))
+		for _, row := range healing.DoneRanking() {
+			if row.Total == 0 {
+				continue
+			}
+			b.WriteString(fmt.Sprintf("  - %-20s: %10d (%.1f hps)\n", f.getUnitName(row.Unit), row.Total, healing.PerSecond(row.Unit, f.Duration())))
+		}
+	}
+
+	// Mana summary
+	var manaHeader bool
+	for _, id := range slices.Sorted(maps.Keys(f.Resources.Units)) {
+		unit := f.Resources.Units[id]
+		gained := unit.Gained[types.ResourceMana]
+		if gained == 0 {
+			continue
+		}
+		if !manaHeader {
+			b.WriteString("\nMana Gained:\n")
+			manaHeader = true
+		}
+		b.WriteString(fmt.Sprintf("  - %-20s: %10d (%d consumables, %d regen)\n", f.getUnitName(id), gained, unit.Consumables(types.ResourceMana), unit.Regen(types.ResourceMana)))
+	}
+
+	// World buffs summary
+	wbs := f.WorldBuffs()
+	if len(wbs) > 0 {
+		b.WriteString("\nWorld Buffs:\n")
+		for _, id := range slices.Sorted(maps.Keys(wbs)) {
+			var names []string
+			for _, buff := range wbs[id] {
+				names = append(names, buff.SpellName)
+			}
+			b.WriteString(fmt.Sprintf("  - %-20s: %s\n", f.getUnitName(id), strings.Join(names, ", ")))
+		}
+	}
+
+	// Consumables summary
+	used := CountByPlayer(f.Consumables())
+	if len(used) > 0 {
+		b.WriteString("\nConsumables:\n")
+		for _, id := range slices.Sorted(maps.Keys(used)) {
+			var items []string
+			for _, item := range slices.Sorted(maps.Keys(used[id])) {
+				items = append(items, fmt.Sprintf("%s x%d", item, used[id][item]))
+			}
+			b.WriteString(fmt.Sprintf("  - %-20s: %s\n", f.getUnitName(id), strings.Join(items, ", ")))
+		}
+	}
+
+	// Avoidable damage summary
+	if avoidable := f.AvoidableDamage(); len(avoidable) > 0 {
+		b.WriteString("\nAvoidable Damage:\n")
+		for _, hit := range avoidable {
+			b.WriteString(fmt.Sprintf("  - %-20s: %-20s %3d hits %10d\n", f.getUnitName(hit.Player), hit.SpellName, hit.Hits, hit.Total))
+		}
+	}
+
+	// Deaths summary
+	if len(f.Deaths) > 0 {
+		b.WriteString(fmt.Sprintf("\nDeaths: %d\n", len(f.Deaths)))
+		for _, death := range f.Deaths {
+			killer := "Unknown"
+			if death.Killer != nil {
+				killer = f.getUnitName(*death.Killer)
+			}
+			b.WriteString(fmt.Sprintf("  - %s %s killed by %s\n", death.Slain.Date().Format("15:04:05"), f.getUnitName(death.Victim), killer))
+		}
+	}
+
+	// Casts summary, players only
+	if f.IsStarted() {
+		start, end := f.Start.Date(), f.Start.Date().Add(f.Duration())
+		var header bool
+		for _, id := range slices.Sorted(maps.Keys(f.Casts.Units)) {
+			unit := f.Casts.Units[id]
+			if !id.IsPlayer() {
+				continue
+			}
+			completed := unit.Completed(start, end)
+			if completed == 0 {
+				continue
+			}
+			if !header {
+				b.WriteString("\nCasts:\n")
+				header = true
+			}
+			b.WriteString(fmt.Sprintf("  - %-20s: %5d (%.1f cpm)\n", f.getUnitName(id), completed, unit.CPM(start, end)))
+		}
+	}
+
+	// Interrupts summary
+	if len(f.Interrupts.Interrupts) > 0 {
+		b.WriteString(fmt.Sprintf("\nInterrupts: %d\n", len(f.Interrupts.Interrupts)))
+		for _, kick := range f.Interrupts.Interrupts {
+			ability := ""
+			if kick.Ability != "" {
+				ability = fmt.Sprintf(" (%s)", kick.Ability)
+			}
+			b.WriteString(fmt.Sprintf("  - %s %s%s interrupted %s's %s\n", kick.At.Format("15:04:05"), f.getUnitName(kick.Caster), ability, f.getUnitName(kick.Target), kick.SpellName))
+		}
+	}
+	if len(f.Interrupts.Uninterrupted) > 0 {
+		b.WriteString(fmt.Sprintf("Uninterrupted hostile casts: %d\n", len(f.Interrupts.Uninterrupted)))
+	}
+
+	// Dispels summary
+	if len(f.Interrupts.Dispels) > 0 {
+		b.WriteString(fmt.Sprintf("\nDispels: %d\n", len(f.Interrupts.Dispels)))
+		for _, dispel := range f.Interrupts.Dispels {
+			dispeller := "Unknown"
+			if dispel.Dispeller != nil {
+				dispeller = f.getUnitName(*dispel.Dispeller)
+			}
+			b.WriteString(fmt.Sprintf("  - %s %s removed %s from %s\n", dispel.At.Format("15:04:05"), dispeller, dispel.SpellName, f.getUnitName(dispel.Target)))
+		}
+	}
+
+	// Aggro summary
+	aggro := f.Threat.AggroChanges()
+	if len(aggro) > 0 {
+		b.WriteString(fmt.Sprintf("\nAggro Changes: %d\n", len(aggro)))
+		for _, change := range aggro {
+			from := "pull"
+			if !change.From.IsZero() {
+				from = f.getUnitName(change.From)
+			}
+			taunt := ""
+			if change.Taunt {
+				taunt = " (taunt)"
+			}
+			b.WriteString(fmt.Sprintf("  - %s %s: %s -> %s%s\n", change.At.Format("15:04:05"), f.getUnitName(change.Enemy), from, f.getUnitName(change.To), taunt))
+		}
+	}
 
 	// Units summary
 	//totalUnits := len(f.Units.Units)
@@ -0,0 +1,137 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/internal/ptr"
+	"github.com/Emyrk/chronicle/golang/internal/testutil"
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/unitinfo"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testPriest  guid.GUID = 0x000000000001C7AC
+	testWarrior guid.GUID = 0x0000000000062A1B
+	testBoss    guid.GUID = 0xF130000950003FB5
+)
+
+var testStart = time.Date(2025, 11, 18, 20, 0, 0, 0, time.UTC)
+
+func at(sec float64) messages.MessageBase {
+	return messages.Base(testStart.Add(time.Duration(sec * float64(time.Second))))
+}
+
+func newTestState(t *testing.T) *State {
+	t.Helper()
+
+	s := NewState(testutil.Logger(t), types.Unit{Name: "Doyd", Gid: testPriest})
+	for _, info := range []unitinfo.Info{
+		{Guid: testPriest, Name: "Doyd", IsPlayer: true, CanCooperate: true},
+		{Guid: testWarrior, Name: "Tankman", IsPlayer: true, CanCooperate: true},
+		{Guid: testBoss, Name: "Gray Bear", CanCooperate: false},
+	} {
+		require.NoError(t, s.Process(messages.Unit{MessageBase: at(0), Info: info}))
+	}
+	return s
+}
+
+func process(t *testing.T, s *State, msgs ...messages.Message) {
+	t.Helper()
+	for _, m := range msgs {
+		require.NoError(t, s.Process(m))
+	}
+}
+
+func TestFightMeters(t *testing.T) {
+	t.Parallel()
+
+	s := newTestState(t)
+	process(t, s,
+		// Healing before the fight starts is not counted
+		messages.Heal{MessageBase: at(0.5), Caster: testPriest, Target: testWarrior, SpellName: "Renew", Amount: 100, HitType: types.HitTypeHit},
+		messages.Damage{MessageBase: at(1), Caster: testWarrior, Target: testBoss, SpellName: ptr.Ref("Heroic Strike"), Amount: 400, HitType: types.HitTypeHit},
+		messages.Damage{MessageBase: at(2), Caster: testBoss, Target: testWarrior, Amount: 200, HitType: types.HitTypeHit},
+		messages.Heal{MessageBase: at(3), Caster: testPriest, Target: testWarrior, SpellName: "Flash Heal", Amount: 600, HitType: types.HitTypeCrit},
+		messages.Damage{MessageBase: at(5), Caster: testWarrior, Target: testBoss, Amount: 600, HitType: types.HitTypeCrit},
+		messages.Slain{MessageBase: at(5), Victim: testBoss, Killer: ptr.Ref(testWarrior)},
+	)
+
+	fight := s.Fights.Fights[0]
+	require.True(t, fight.IsDone())
+	require.Equal(t, 4*time.Second, fight.Duration())
+
+	require.Equal(t, int64(1000), fight.DamageMeter.Done[testWarrior].Total)
+	require.Equal(t, int64(600), fight.DamageMeter.Done[testWarrior].BySpell[MeleeSpellName].Total)
+	require.Equal(t, int64(200), fight.DamageMeter.Taken[testWarrior].Total)
+	require.InDelta(t, 250.0, fight.DPS(testWarrior), 0.001)
+
+	require.Equal(t, int64(600), fight.HealingMeter.Total)
+	require.Equal(t, 1, fight.HealingMeter.Done[testPriest].Crits)
+	require.Equal(t, int64(600), fight.HealingMeter.Taken[testWarrior].ByUnit[testPriest])
+	require.InDelta(t, 150.0, fight.HPS(testPriest), 0.001)
+}
+
+func TestFightTrailingDamage(t *testing.T) {
+	t.Parallel()
+
+	s := newTestState(t)
+	process(t, s,
+		messages.Damage{MessageBase: at(1), Caster: testWarrior, Target: testBoss, Amount: 400, HitType: types.HitTypeHit},
+		messages.Heal{MessageBase: at(2), Caster: testPriest, Target: testWarrior, SpellName: "Flash Heal", Amount: 600, HitType: types.HitTypeHit},
+		messages.Damage{MessageBase: at(3), Caster: testBoss, Target: testWarrior, Amount: 900, HitType: types.HitTypeHit},
+		messages.Slain{MessageBase: at(4.5), Victim: testWarrior, Killer: ptr.Ref(testBoss)},
+		messages.Slain{MessageBase: at(5), Victim: testBoss, Killer: ptr.Ref(testPriest)},
+	)
+	require.True(t, s.Fights.Fights[0].IsDone())
+
+	// Both units died in the previous fight, so this is a late line and not
+	// a new pull.
+	process(t, s,
+		messages.Damage{MessageBase: at(5.2), Caster: testWarrior, Target: testBoss, Amount: 100, HitType: types.HitTypeHit},
+	)
+	require.False(t, s.Fights.CurrentFight.IsStarted())
+
+	// The target is still alive, so this starts a new fight.
+	process(t, s,
+		messages.Damage{MessageBase: at(5.5), Caster: testBoss, Target: testPriest, Amount: 100, HitType: types.HitTypeHit},
+	)
+	require.True(t, s.Fights.CurrentFight.IsStarted())
+}
+
+func TestFightTimeout(t *testing.T) {
+	t.Parallel()
+
+	s := newTestState(t)
+	process(t, s,
+		messages.Damage{MessageBase: at(1), Caster: testWarrior, Target: testBoss, Amount: 100, HitType: types.HitTypeHit},
+		messages.Damage{MessageBase: at(2), Caster: testBoss, Target: testWarrior, Amount: 100, HitType: types.HitTypeHit},
+		// The boss evades, the next pull is much later
+		messages.Damage{MessageBase: at(2 + DefaultCombatTimeout.Seconds() + 5), Caster: testWarrior, Target: testBoss, Amount: 100, HitType: types.HitTypeHit},
+	)
+
+	require.Len(t, s.Fights.Fights, 2)
+	first := s.Fights.Fights[0]
+	require.True(t, first.IsDone())
+	require.Equal(t, EndReasonTimeout, first.EndReason)
+	require.Equal(t, OutcomeReset, first.Outcome)
+	require.Equal(t, time.Second, first.Duration(), "idle time is not part of the fight")
+
+	second := s.Fights.Fights[1]
+	require.True(t, second.IsStarted())
+	require.Equal(t, int64(100), second.DamageMeter.Total)
+
+	t.Run("Disabled", func(t *testing.T) {
+		t.Parallel()
+
+		s := NewState(testutil.Logger(t), types.Unit{Name: "Doyd", Gid: testPriest}, WithCombatTimeout(0))
+		process(t, s,
+			messages.Damage{MessageBase: at(1), Caster: testWarrior, Target: testBoss, Amount: 100, HitType: types.HitTypeHit},
+			messages.Damage{MessageBase: at(600), Caster: testWarrior, Target: testBoss, Amount: 100, HitType: types.HitTypeHit},
+		)
+		require.Len(t, s.Fights.Fights, 1)
+	})
+}
@@ -0,0 +1,171 @@
+package state
+
+import (
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+)
+
+// abilityWindow is how long before an interrupt or dispel line the ability
+// that caused it can be cast.
+const abilityWindow = 2 * time.Second
+
+// interruptSpells are abilities that interrupt casts. The interrupt line does
+// not include the ability, so it is taken from the interrupter's last cast.
+var interruptSpells = map[string]bool{
+	"Kick":                true,
+	"Pummel":              true,
+	"Shield Bash":         true,
+	"Counterspell":        true,
+	"Earth Shock":         true,
+	"Concussion Blow":     true,
+	"Feral Charge Effect": true,
+	"Spell Lock":          true,
+}
+
+// dispelSpells are abilities that remove auras. The removed line does not
+// include who removed it, so it is taken from the most recent dispel cast on
+// the target.
+var dispelSpells = map[string]bool{
+	"Dispel Magic":        true,
+	"Cleanse":             true,
+	"Purify":              true,
+	"Purge":               true,
+	"Remove Curse":        true,
+	"Remove Lesser Curse": true,
+	"Cure Poison":         true,
+	"Abolish Poison":      true,
+	"Cure Disease":        true,
+	"Abolish Disease":     true,
+	"Devour Magic":        true,
+	"Tranquilizing Shot":  true,
+}
+
+// InterruptRecord is a single successful interrupt.
+type InterruptRecord struct {
+	At time.Time
+	// Caster is the unit that did the interrupt.
+	Caster guid.GUID
+	// Ability is the interrupting ability, if it was seen cast.
+	Ability string
+	// Target is the unit that was interrupted.
+	Target guid.GUID
+	// SpellName is the spell that was interrupted.
+	SpellName string
+	// Attempt is the interrupted cast, if the "begins to cast" line was seen.
+	Attempt *CastAttempt
+}
+
+// DispelRecord is a single aura removed by a dispel.
+type DispelRecord struct {
+	At time.Time
+	// Dispeller is nil if no dispel cast on the target was seen.
+	Dispeller *guid.GUID
+	Ability   string
+	Target    guid.GUID
+	// SpellName is the aura that was removed.
+	SpellName string
+	Harmful   bool
+}
+
+// Interrupts tracks interrupts, dispels and the casts that could have been
+// interrupted during a fight.
+type Interrupts struct {
+	s     *State
+	casts *Casts
+
+	Interrupts []InterruptRecord
+	Dispels    []DispelRecord
+	// Uninterrupted are hostile casts with a cast time that completed.
+	Uninterrupted []*CastAttempt
+
+	// lastInterrupt is the last interrupt ability cast by each unit.
+	lastInterrupt map[guid.GUID]messages.Cast
+	// lastDispel is the last dispel cast on each target.
+	lastDispel map[guid.GUID]messages.Cast
+}
+
+// NewInterrupts uses the pending casts to match interrupts to the interrupted
+// cast. Messages must be processed by Interrupts before Casts.
+func NewInterrupts(s *State, casts *Casts) *Interrupts {
+	return &Interrupts{
+		s:             s,
+		casts:         casts,
+		lastInterrupt: make(map[guid.GUID]messages.Cast),
+		lastDispel:    make(map[guid.GUID]messages.Cast),
+	}
+}
+
+func (i *Interrupts) Process(m messages.Message) {
+	switch typed := m.(type) {
+	case messages.Cast:
+		i.Cast(typed)
+	case messages.Interrupt:
+		i.Interrupt(typed)
+	case messages.Aura:
+		if typed.Application == types.AuraApplicationRemoved {
+			i.Removed(typed)
+		}
+	}
+}
+
+func (i *Interrupts) Cast(c messages.Cast) {
+	if c.Action != types.CastActionsCasts {
+		return
+	}
+
+	caster := c.Caster.Gid
+	if interruptSpells[c.Spell.Name] {
+		i.lastInterrupt[caster] = c
+	}
+	if dispelSpells[c.Spell.Name] && c.Target != nil {
+		i.lastDispel[c.Target.Gid] = c
+	}
+
+	// The pending cast is completed by this line once Casts processes it
+	attempt := i.casts.Pending(caster)
+	if attempt != nil && attempt.Spell.Name == c.Spell.Name && !i.s.IsFriendly(caster) {
+		i.Uninterrupted = append(i.Uninterrupted, attempt)
+	}
+}
+
+func (i *Interrupts) Interrupt(m messages.Interrupt) {
+	record := InterruptRecord{
+		At:        m.Date(),
+		Caster:    m.Caster,
+		Target:    m.Target,
+		SpellName: m.SpellName,
+	}
+
+	if last, ok := i.lastInterrupt[m.Caster]; ok && m.Date().Sub(last.Date()) <= abilityWindow {
+		record.Ability = last.Spell.Name
+	}
+
+	if attempt := i.casts.Pending(m.Target); attempt != nil && attempt.Spell.Name == m.SpellName {
+		record.Attempt = attempt
+	}
+
+	i.Interrupts = append(i.Interrupts, record)
+}
+
+// Removed handles "X's Y is removed." lines, which are dispels.
+func (i *Interrupts) Removed(a messages.Aura) {
+	record := DispelRecord{
+		At:        a.Date(),
+		Target:    a.Target,
+		SpellName: a.SpellName,
+	}
+	if t, ok := i.s.Auras.Units[a.Target][a.SpellName]; ok {
+		record.Harmful = t.Harmful
+	}
+
+	if last, ok := i.lastDispel[a.Target]; ok && a.Date().Sub(last.Date()) <= abilityWindow {
+		dispeller := last.Caster.Gid
+		record.Dispeller = &dispeller
+		record.Ability = last.Spell.Name
+	}
+
+	i.Dispels = append(i.Dispels, record)
+}
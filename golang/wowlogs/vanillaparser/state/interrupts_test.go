@@ -0,0 +1,58 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/castv2"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterrupts(t *testing.T) {
+	t.Parallel()
+
+	cast := func(sec float64, caster types.Unit, action types.CastActions, spell string, target *types.Unit) messages.Cast {
+		return messages.Cast{MessageBase: at(sec), CastV2: castv2.CastV2{
+			Caster: caster,
+			Action: action,
+			Target: target,
+			Spell:  types.Spell{Name: spell},
+		}}
+	}
+	warrior, priest, boss := types.Unit{Gid: testWarrior}, types.Unit{Gid: testPriest}, types.Unit{Gid: testBoss}
+
+	s := newTestState(t)
+	process(t, s,
+		messages.Damage{MessageBase: at(1), Caster: testWarrior, Target: testBoss, Amount: 100, HitType: types.HitTypeHit},
+		// Kicked
+		cast(2, boss, types.CastActionsBeginsToCast, "Shadow Bolt", &warrior),
+		cast(3, warrior, types.CastActionsCasts, "Pummel", &boss),
+		messages.Interrupt{MessageBase: at(3), Caster: testWarrior, Target: testBoss, SpellName: "Shadow Bolt"},
+		// Not kicked
+		cast(4, boss, types.CastActionsBeginsToCast, "Fear", &warrior),
+		cast(5.5, boss, types.CastActionsCasts, "Fear", &warrior),
+		// Dispelled
+		messages.Aura{MessageBase: at(5.5), Target: testWarrior, SpellName: "Fear", Application: types.AuraApplicationGains, Harmful: true},
+		cast(6, priest, types.CastActionsCasts, "Dispel Magic", &warrior),
+		messages.Aura{MessageBase: at(6), Target: testWarrior, SpellName: "Fear", Application: types.AuraApplicationRemoved},
+	)
+
+	i := s.Fights.CurrentFight.Interrupts
+	require.Len(t, i.Interrupts, 1)
+	kick := i.Interrupts[0]
+	require.Equal(t, "Pummel", kick.Ability)
+	require.Equal(t, "Shadow Bolt", kick.SpellName)
+	require.NotNil(t, kick.Attempt)
+	require.Equal(t, CastResultInterrupted, kick.Attempt.Result)
+
+	require.Len(t, i.Uninterrupted, 1)
+	require.Equal(t, "Fear", i.Uninterrupted[0].Spell.Name)
+	require.Equal(t, CastResultCompleted, i.Uninterrupted[0].Result)
+
+	require.Len(t, i.Dispels, 1)
+	require.NotNil(t, i.Dispels[0].Dispeller)
+	require.Equal(t, testPriest, *i.Dispels[0].Dispeller)
+	require.Equal(t, "Dispel Magic", i.Dispels[0].Ability)
+	require.True(t, i.Dispels[0].Harmful)
+}
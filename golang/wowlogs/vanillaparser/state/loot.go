@@ -0,0 +1,70 @@
+package state
+
+import (
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/encounters"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+)
+
+// LootLog is every item looted during the log.
+type LootLog struct {
+	Drops []LootDrop
+}
+
+// LootDrop is a single looted item, with the boss it most likely came from.
+type LootDrop struct {
+	messages.Loot
+	// Receiver is the guid of the player that got the item. Zero if the line
+	// only had a name that could not be resolved.
+	Receiver guid.GUID
+	// FightIndex is the index in Fights.Fights of the most recent boss kill
+	// before the drop. -1 if there was no boss kill before it.
+	FightIndex int
+	Encounter  *encounters.Encounter
+}
+
+func NewLootLog() *LootLog {
+	return &LootLog{}
+}
+
+// Loot records the drop. Boss loot can only be taken after the boss is dead,
+// so the drop is attributed to the most recent boss kill.
+func (l *LootLog) Loot(m messages.Loot, units *Units, fights *Fights) LootDrop {
+	drop := LootDrop{
+		Loot:       m,
+		Receiver:   m.Loot.Receiver.Gid,
+		FightIndex: -1,
+	}
+	if drop.Receiver.IsZero() && m.Loot.Receiver.Name != "" {
+		if id, ok := units.PlayerByName(m.Loot.Receiver.Name); ok {
+			drop.Receiver = id
+		}
+	}
+
+	for i := len(fights.Fights) - 1; i >= 0; i-- {
+		f := fights.Fights[i]
+		if !f.IsDone() || f.Encounter == nil || f.Outcome != OutcomeKill {
+			continue
+		}
+		if f.End.Date().After(m.Date()) {
+			continue
+		}
+		drop.FightIndex = i
+		drop.Encounter = f.Encounter
+		break
+	}
+
+	l.Drops = append(l.Drops, drop)
+	return drop
+}
+
+// ByFight returns the drops attributed to the fight at the index.
+func (l *LootLog) ByFight(index int) []LootDrop {
+	var drops []LootDrop
+	for _, d := range l.Drops {
+		if d.FightIndex == index {
+			drops = append(drops, d)
+		}
+	}
+	return drops
+}
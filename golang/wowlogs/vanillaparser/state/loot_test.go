@@ -0,0 +1,50 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/Emyrk/chronicle/golang/internal/ptr"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/loot"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/unitinfo"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLootLog(t *testing.T) {
+	t.Parallel()
+
+	lucifron := creature(12118, 1)
+	drop := func(sec float64, receiver types.Unit, item string) messages.Loot {
+		return messages.Loot{
+			MessageBase: at(sec),
+			Loot: loot.Loot{
+				Receiver: receiver,
+				Quality:  loot.QualityEpic,
+				ItemName: item,
+				Count:    1,
+			},
+		}
+	}
+
+	s := newTestState(t)
+	process(t, s,
+		messages.Unit{MessageBase: at(0), Info: unitinfo.Info{Guid: lucifron, Name: "Lucifron"}},
+		// Trash loot before any boss
+		drop(0.5, types.Unit{Gid: testWarrior}, "Lava Core"),
+		messages.Damage{MessageBase: at(1), Caster: testWarrior, Target: lucifron, Amount: 100, HitType: types.HitTypeHit},
+		messages.Damage{MessageBase: at(2), Caster: lucifron, Target: testWarrior, Amount: 100, HitType: types.HitTypeHit},
+		messages.Slain{MessageBase: at(3), Victim: lucifron, Killer: ptr.Ref(testWarrior)},
+		drop(60, types.Unit{Name: "Doyd"}, "Choker of Enlightenment"),
+	)
+
+	require.Len(t, s.Loot.Drops, 2)
+	require.Equal(t, -1, s.Loot.Drops[0].FightIndex)
+	require.Nil(t, s.Loot.Drops[0].Encounter)
+
+	boss := s.Loot.Drops[1]
+	require.Equal(t, 0, boss.FightIndex)
+	require.Equal(t, "Lucifron", boss.Encounter.Name)
+	require.Equal(t, testPriest, boss.Receiver, "receiver resolved by name")
+	require.Len(t, s.Loot.ByFight(0), 1)
+}
@@ -0,0 +1,204 @@
+package state
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+)
+
+const (
+	// MeleeSpellName is used for damage without a spell name, like auto attacks.
+	MeleeSpellName = "Melee"
+	// DamageShieldSpellName is used for reflected damage without a spell name,
+	// like Thorns.
+	DamageShieldSpellName = "Damage Shield"
+)
+
+// Meter aggregates amounts between units, like damage or healing.
+// Everything is tracked twice, once from the perspective of the caster (Done)
+// and once from the perspective of the target (Taken).
+type Meter struct {
+	Total int64
+	// Done is keyed by the unit that caused the amount.
+	Done map[guid.GUID]*MeterEntry
+	// Taken is keyed by the unit that received the amount.
+	Taken map[guid.GUID]*MeterEntry
+}
+
+// MeterEntry is the breakdown for a single unit.
+type MeterEntry struct {
+	Total int64
+	Count int
+	Crits int
+	// ByUnit is keyed by the other unit of the event. For "Done" entries this
+	// is the target, for "Taken" entries this is the caster.
+	ByUnit   map[guid.GUID]int64
+	BySpell  map[string]*SpellAmount
+	BySchool map[types.School]int64
+}
+
+type SpellAmount struct {
+	Total int64
+	Count int
+	// Hits is the number of events that did any damage or healing. Count also
+	// includes misses and full resists.
+	Hits  int
+	Crits int
+}
+
+// MeterEvent is a single damage or heal event to add to a meter.
+type MeterEvent struct {
+	Caster  guid.GUID
+	Target  guid.GUID
+	Spell   string
+	School  types.School
+	Amount  int32
+	HitType types.HitType
+}
+
+// UnitAmount is a single row of a meter ranking.
+type UnitAmount struct {
+	Unit  guid.GUID
+	Total int64
+}
+
+func NewMeter() *Meter {
+	return &Meter{
+		Done:  make(map[guid.GUID]*MeterEntry),
+		Taken: make(map[guid.GUID]*MeterEntry),
+	}
+}
+
+func newMeterEntry() *MeterEntry {
+	return &MeterEntry{
+		ByUnit:   make(map[guid.GUID]int64),
+		BySpell:  make(map[string]*SpellAmount),
+		BySchool: make(map[types.School]int64),
+	}
+}
+
+func (m *Meter) Add(e MeterEvent) {
+	m.Total += int64(e.Amount)
+
+	done, ok := m.Done[e.Caster]
+	if !ok {
+		done = newMeterEntry()
+		m.Done[e.Caster] = done
+	}
+	done.add(e.Target, e)
+
+	taken, ok := m.Taken[e.Target]
+	if !ok {
+		taken = newMeterEntry()
+		m.Taken[e.Target] = taken
+	}
+	taken.add(e.Caster, e)
+}
+
+func (me *MeterEntry) add(other guid.GUID, e MeterEvent) {
+	crit := e.HitType.Has(types.HitTypeCrit)
+
+	me.Total += int64(e.Amount)
+	me.Count++
+	if crit {
+		me.Crits++
+	}
+	me.ByUnit[other] += int64(e.Amount)
+
+	spell, ok := me.BySpell[e.Spell]
+	if !ok {
+		spell = &SpellAmount{}
+		me.BySpell[e.Spell] = spell
+	}
+	spell.Total += int64(e.Amount)
+	spell.Count++
+	if e.Amount > 0 {
+		spell.Hits++
+	}
+	if crit {
+		spell.Crits++
+	}
+
+	if e.Amount > 0 && e.School != types.None {
+		me.BySchool[e.School] += int64(e.Amount)
+	}
+}
+
+// Fold returns a copy of the meter with every unit replaced by the unit
+// returned from owner. This is used to fold pets, totems and guardians into
+// their owners.
+func (m *Meter) Fold(owner func(id guid.GUID) guid.GUID) *Meter {
+	folded := NewMeter()
+	folded.Total = m.Total
+	foldEntries(folded.Done, m.Done, owner)
+	foldEntries(folded.Taken, m.Taken, owner)
+	return folded
+}
+
+func foldEntries(dst, src map[guid.GUID]*MeterEntry, owner func(id guid.GUID) guid.GUID) {
+	for id, entry := range src {
+		into, ok := dst[owner(id)]
+		if !ok {
+			into = newMeterEntry()
+			dst[owner(id)] = into
+		}
+
+		into.Total += entry.Total
+		into.Count += entry.Count
+		into.Crits += entry.Crits
+		for other, amount := range entry.ByUnit {
+			into.ByUnit[owner(other)] += amount
+		}
+		for name, spell := range entry.BySpell {
+			intoSpell, ok := into.BySpell[name]
+			if !ok {
+				intoSpell = &SpellAmount{}
+				into.BySpell[name] = intoSpell
+			}
+			intoSpell.Total += spell.Total
+			intoSpell.Count += spell.Count
+			intoSpell.Hits += spell.Hits
+			intoSpell.Crits += spell.Crits
+		}
+		for school, amount := range entry.BySchool {
+			into.BySchool[school] += amount
+		}
+	}
+}
+
+// PerSecond returns the amount done per second by the unit over the duration.
+func (m *Meter) PerSecond(id guid.GUID, dur time.Duration) float64 {
+	entry, ok := m.Done[id]
+	if !ok || dur <= 0 {
+		return 0
+	}
+	return float64(entry.Total) / dur.Seconds()
+}
+
+// DoneRanking returns all units sorted by the amount they did, highest first.
+func (m *Meter) DoneRanking() []UnitAmount {
+	return ranking(m.Done)
+}
+
+// TakenRanking returns all units sorted by the amount they received, highest first.
+func (m *Meter) TakenRanking() []UnitAmount {
+	return ranking(m.Taken)
+}
+
+func ranking(entries map[guid.GUID]*MeterEntry) []UnitAmount {
+	rows := make([]UnitAmount, 0, len(entries))
+	for id, entry := range entries {
+		rows = append(rows, UnitAmount{Unit: id, Total: entry.Total})
+	}
+	slices.SortFunc(rows, func(a, b UnitAmount) int {
+		if c := cmp.Compare(b.Total, a.Total); c != 0 {
+			return c
+		}
+		// Stable output for equal amounts
+		return cmp.Compare(a.Unit, b.Unit)
+	})
+	return rows
+}
@@ -0,0 +1,50 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMeter(t *testing.T) {
+	t.Parallel()
+
+	const (
+		warrior guid.GUID = 0x0000000000062A1B
+		mage    guid.GUID = 0x0000000000016541
+		boss    guid.GUID = 0xF130000950003FB5
+	)
+
+	m := NewMeter()
+	m.Add(MeterEvent{Caster: warrior, Target: boss, Spell: MeleeSpellName, School: types.PhysicalSchool, Amount: 300, HitType: types.HitTypeHit})
+	m.Add(MeterEvent{Caster: warrior, Target: boss, Spell: "Hamstring", School: types.PhysicalSchool, Amount: 100, HitType: types.HitTypeCrit})
+	m.Add(MeterEvent{Caster: mage, Target: boss, Spell: "Fireball", School: types.FireSchool, Amount: 1000, HitType: types.HitTypeCrit})
+	m.Add(MeterEvent{Caster: mage, Target: boss, Spell: "Fireball", School: types.FireSchool, Amount: 0, HitType: types.HitTypeFullResist})
+	m.Add(MeterEvent{Caster: boss, Target: warrior, Spell: MeleeSpellName, School: types.PhysicalSchool, Amount: 500, HitType: types.HitTypeHit})
+
+	require.Equal(t, int64(1900), m.Total)
+
+	mageDone := m.Done[mage]
+	require.Equal(t, int64(1000), mageDone.Total)
+	require.Equal(t, 2, mageDone.Count)
+	require.Equal(t, 1, mageDone.Crits)
+	require.Equal(t, &SpellAmount{Total: 1000, Count: 2, Hits: 1, Crits: 1}, mageDone.BySpell["Fireball"])
+	require.Equal(t, map[types.School]int64{types.FireSchool: 1000}, mageDone.BySchool)
+
+	bossTaken := m.Taken[boss]
+	require.Equal(t, int64(1400), bossTaken.Total)
+	require.Equal(t, map[guid.GUID]int64{warrior: 400, mage: 1000}, bossTaken.ByUnit)
+
+	require.Equal(t, []UnitAmount{
+		{Unit: mage, Total: 1000},
+		{Unit: boss, Total: 500},
+		{Unit: warrior, Total: 400},
+	}, m.DoneRanking())
+
+	require.InDelta(t, 100.0, m.PerSecond(mage, 10*time.Second), 0.001)
+	require.Zero(t, m.PerSecond(mage, 0))
+	require.Zero(t, m.PerSecond(0x1, 10*time.Second))
+}
@@ -0,0 +1,188 @@
+package state
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/totems"
+)
+
+// summonWindow is how long after a summon cast a new unit can be matched to
+// the summoner.
+const summonWindow = 5 * time.Second
+
+// Owners keeps track of what player owns which pets, totems and guardians.
+//
+// Owners come from 3 sources:
+//   - UNIT_INFO lines with an owner.
+//   - COMBATANT_INFO pet names matched to pet units with the same name.
+//   - CAST lines where a player summons a totem or guardian, followed by the
+//     summoned unit casting for the first time.
+//
+// Raw logs
+// 11/20 18:29:17.454  CAST: 0x0000000000024225(Oku) casts Magma Totem(10586)(Rank 3).
+// 11/20 18:29:17.454  0x0000000000024225 casts Magma Totem.
+// 11/20 18:29:19.474  CAST: 0xF130001D29279311(Magma Totem III) casts Magma Totem(10580)(Rank 3) on 0xF130001D29279311(Magma Totem III).
+// 11/20 18:29:19.474  0xF130001D29279311's Magma Totem hits 0xF13000ED342738CD for 54 Fire damage.
+//
+// Normal logs
+// 11/20 18:29:19.474  Magma Totem III (Oku)'s Magma Totem hits Hateforge Warden for 54 Fire damage.
+type Owners struct {
+	// OwnerOf is keyed by the owned unit.
+	OwnerOf map[guid.GUID]guid.GUID
+	// Owned is keyed by the owner.
+	Owned map[guid.GUID][]guid.GUID
+
+	// petNames maps a player's pet name to the player.
+	petNames map[string]guid.GUID
+	pending  []pendingSummon
+}
+
+type pendingSummon struct {
+	Owner     guid.GUID
+	SpellName string
+	At        time.Time
+}
+
+func NewOwners() *Owners {
+	return &Owners{
+		OwnerOf:  make(map[guid.GUID]guid.GUID),
+		Owned:    make(map[guid.GUID][]guid.GUID),
+		petNames: make(map[string]guid.GUID),
+	}
+}
+
+// Set records the owner of a unit.
+func (o *Owners) Set(unit, owner guid.GUID) {
+	if unit == owner || unit.IsZero() || owner.IsZero() {
+		return
+	}
+	if existing, ok := o.OwnerOf[unit]; ok {
+		if existing == owner {
+			return
+		}
+		o.Owned[existing] = slices.DeleteFunc(o.Owned[existing], func(id guid.GUID) bool {
+			return id == unit
+		})
+	}
+	o.OwnerOf[unit] = owner
+	o.Owned[owner] = append(o.Owned[owner], unit)
+}
+
+// Get returns the direct owner of the unit.
+func (o *Owners) Get(unit guid.GUID) (guid.GUID, bool) {
+	owner, ok := o.OwnerOf[unit]
+	return owner, ok
+}
+
+// Resolve returns the top level owner of the unit, or the unit itself if it
+// has no owner. A totem summoned by a pet resolves to the pet's owner.
+func (o *Owners) Resolve(unit guid.GUID) guid.GUID {
+	// Bound the depth to protect against cycles
+	for range 5 {
+		owner, ok := o.OwnerOf[unit]
+		if !ok {
+			return unit
+		}
+		unit = owner
+	}
+	return unit
+}
+
+// Unit handles UNIT_INFO lines. The owner is either in the line itself, or
+// the unit is a pet with a name from a COMBATANT_INFO line.
+func (o *Owners) Unit(u messages.Unit) {
+	if u.Owner != nil {
+		o.Set(u.Guid, *u.Owner)
+		return
+	}
+
+	if u.Guid.IsPet() {
+		if owner, ok := o.petNames[u.Name]; ok {
+			o.Set(u.Guid, owner)
+		}
+	}
+}
+
+// Combatant handles COMBATANT_INFO lines, which include the player's pet name.
+func (o *Owners) Combatant(c messages.Combatant, units *Units) {
+	if c.PetName == "" {
+		return
+	}
+	o.petNames[c.PetName] = c.Guid
+
+	for id, info := range units.Info {
+		if !id.IsPet() || info.Name != c.PetName {
+			continue
+		}
+		if _, ok := o.OwnerOf[id]; ok {
+			continue
+		}
+		o.Set(id, c.Guid)
+	}
+}
+
+// Cast handles summons. A player casting a spell without a target might be a
+// summon. The next unknown unit with a matching name to cast something is
+// considered summoned by that player.
+func (o *Owners) Cast(c messages.Cast) {
+	o.prune(c.Date())
+
+	caster := c.Caster.Gid
+	if caster.IsPlayer() {
+		if c.Target == nil && c.Action == types.CastActionsCasts {
+			o.pending = append(o.pending, pendingSummon{
+				Owner:     caster,
+				SpellName: c.Spell.Name,
+				At:        c.Date(),
+			})
+		}
+		return
+	}
+
+	if _, ok := o.OwnerOf[caster]; ok {
+		return
+	}
+
+	name := c.Caster.Name
+	if name == "Unknown" {
+		name = ""
+	}
+
+	// Most recent summon first
+	for i := len(o.pending) - 1; i >= 0; i-- {
+		p := o.pending[i]
+		if !summonMatches(p.SpellName, caster, name) {
+			continue
+		}
+		o.Set(caster, p.Owner)
+		o.pending = append(o.pending[:i], o.pending[i+1:]...)
+		return
+	}
+}
+
+func (o *Owners) prune(now time.Time) {
+	keep := o.pending[:0]
+	for _, p := range o.pending {
+		if now.Sub(p.At) <= summonWindow {
+			keep = append(keep, p)
+		}
+	}
+	o.pending = keep
+}
+
+// summonMatches checks if the summon spell could have created the unit.
+// "Magma Totem" creates "Magma Totem III", "Summon Imp" creates "Imp".
+func summonMatches(spellName string, unit guid.GUID, unitName string) bool {
+	expected := strings.TrimPrefix(spellName, "Summon ")
+	if unitName != "" && strings.HasPrefix(unitName, expected) {
+		return true
+	}
+
+	// Unnamed totems can still be matched to a totem spell.
+	return unitName == "" && totems.IsTotem(unit) && strings.HasSuffix(spellName, "Totem")
+}
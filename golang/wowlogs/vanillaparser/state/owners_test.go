@@ -0,0 +1,81 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/Emyrk/chronicle/golang/internal/ptr"
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/castv2"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/combatant"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/unitinfo"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwners(t *testing.T) {
+	t.Parallel()
+
+	const (
+		shaman     guid.GUID = 0x0000000000024225
+		magmaTotem guid.GUID = 0xF130001D29279311
+		hunter     guid.GUID = 0x00000000000FC54E
+		hunterPet  guid.GUID = 0xF1400844930090A2
+		warlock    guid.GUID = 0x00000000000EB167
+		imp        guid.GUID = 0xF140084493000090
+	)
+
+	cast := func(sec float64, caster types.Unit, spell string, target *types.Unit) messages.Cast {
+		return messages.Cast{
+			MessageBase: at(sec),
+			CastV2: castv2.CastV2{
+				Caster: caster,
+				Action: types.CastActionsCasts,
+				Target: target,
+				Spell:  types.Spell{Name: spell},
+			},
+		}
+	}
+
+	s := newTestState(t)
+	totemUnit := types.Unit{Name: "Magma Totem III", Gid: magmaTotem}
+	process(t, s,
+		// Totem summoned by the shaman
+		cast(0, types.Unit{Name: "Oku", Gid: shaman}, "Magma Totem", nil),
+		cast(2, totemUnit, "Magma Totem", &totemUnit),
+		// Hunter pet from COMBATANT_INFO
+		messages.Unit{MessageBase: at(3), Info: unitinfo.Info{Guid: hunterPet, Name: "Naga", CanCooperate: true}},
+		messages.Combatant{MessageBase: at(3), Combatant: combatant.Combatant{Guid: hunter, Name: "Kryaa", PetName: "Naga"}},
+		// Warlock pet from UNIT_INFO
+		messages.Unit{MessageBase: at(4), Info: unitinfo.Info{Guid: imp, Name: "Chotuk", CanCooperate: true, Owner: ptr.Ref(warlock)}},
+		messages.Damage{MessageBase: at(5), Caster: magmaTotem, Target: testBoss, Amount: 54, School: types.FireSchool, HitType: types.HitTypeHit},
+		messages.Damage{MessageBase: at(6), Caster: shaman, Target: testBoss, Amount: 100, HitType: types.HitTypeHit},
+	)
+
+	require.Equal(t, shaman, s.Owners.Resolve(magmaTotem))
+	require.Equal(t, hunter, s.Owners.Resolve(hunterPet))
+	require.Equal(t, warlock, s.Owners.Resolve(imp))
+	require.Equal(t, testBoss, s.Owners.Resolve(testBoss))
+	require.Equal(t, []guid.GUID{magmaTotem}, s.Owners.Owned[shaman])
+
+	fight := s.Fights.CurrentFight
+	require.Equal(t, int64(54), fight.DamageMeter.Done[magmaTotem].Total)
+
+	folded := fight.DamageByOwner()
+	require.Equal(t, int64(154), folded.Done[shaman].Total)
+	require.NotContains(t, folded.Done, magmaTotem)
+	require.Equal(t, int64(154), folded.Taken[testBoss].ByUnit[shaman])
+}
+
+func TestSummonExpires(t *testing.T) {
+	t.Parallel()
+
+	o := NewOwners()
+	shaman := types.Unit{Name: "Oku", Gid: 0x0000000000024225}
+	totem := types.Unit{Name: "Searing Totem V", Gid: 0xF130001D29279311}
+	o.Cast(messages.Cast{MessageBase: at(0), CastV2: castv2.CastV2{Caster: shaman, Action: types.CastActionsCasts, Spell: types.Spell{Name: "Searing Totem"}}})
+	o.Cast(messages.Cast{MessageBase: at(30), CastV2: castv2.CastV2{Caster: totem, Action: types.CastActionsCasts, Spell: types.Spell{Name: "Attack"}}})
+
+	_, ok := o.Get(totem.Gid)
+	require.False(t, ok)
+}
@@ -0,0 +1,122 @@
+package state
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/consumables"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+)
+
+// IsResourceConsumable returns true if the spell is from a consumable item,
+// like a mana potion. Everything else is considered regen from spells and
+// talents.
+func IsResourceConsumable(spellName string) bool {
+	_, ok := consumables.ByName(spellName)
+	return ok
+}
+
+// Resources tracks resource gains and losses of every unit in a fight.
+type Resources struct {
+	Units map[guid.GUID]*UnitResources
+}
+
+// UnitResources are the resource changes of a single unit.
+type UnitResources struct {
+	Gained map[types.Resource]int64
+	Lost   map[types.Resource]int64
+	// Sources is keyed by the resource, then the spell name.
+	Sources map[types.Resource]map[string]*ResourceSource
+}
+
+// ResourceSource is the total gained from a single spell.
+type ResourceSource struct {
+	SpellName  string
+	Consumable bool
+	Amount     int64
+	Count      int
+	// ByCaster is who cast the spell, like the druid casting Innervate.
+	ByCaster map[guid.GUID]int64
+}
+
+func NewResources() *Resources {
+	return &Resources{
+		Units: make(map[guid.GUID]*UnitResources),
+	}
+}
+
+func (r *Resources) Process(m messages.ResourceChange) {
+	unit, ok := r.Units[m.Target]
+	if !ok {
+		unit = &UnitResources{
+			Gained:  make(map[types.Resource]int64),
+			Lost:    make(map[types.Resource]int64),
+			Sources: make(map[types.Resource]map[string]*ResourceSource),
+		}
+		r.Units[m.Target] = unit
+	}
+
+	if m.Direction != "gains" {
+		unit.Lost[m.Resource] += int64(m.Amount)
+		return
+	}
+	unit.Gained[m.Resource] += int64(m.Amount)
+
+	spellName := ""
+	if m.SpellName != nil {
+		spellName = *m.SpellName
+	}
+
+	sources, ok := unit.Sources[m.Resource]
+	if !ok {
+		sources = make(map[string]*ResourceSource)
+		unit.Sources[m.Resource] = sources
+	}
+	source, ok := sources[spellName]
+	if !ok {
+		source = &ResourceSource{
+			SpellName:  spellName,
+			Consumable: IsResourceConsumable(spellName),
+			ByCaster:   make(map[guid.GUID]int64),
+		}
+		sources[spellName] = source
+	}
+	source.Amount += int64(m.Amount)
+	source.Count++
+	if m.Caster != nil && !m.Caster.IsZero() {
+		source.ByCaster[*m.Caster] += int64(m.Amount)
+	}
+}
+
+// SourceRanking returns the sources of the resource, largest first.
+func (u *UnitResources) SourceRanking(resource types.Resource) []ResourceSource {
+	sources := make([]ResourceSource, 0, len(u.Sources[resource]))
+	for _, source := range u.Sources[resource] {
+		sources = append(sources, *source)
+	}
+	slices.SortFunc(sources, func(a, b ResourceSource) int {
+		if c := cmp.Compare(b.Amount, a.Amount); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.SpellName, b.SpellName)
+	})
+	return sources
+}
+
+// Consumables returns the amount of the resource gained from consumables.
+func (u *UnitResources) Consumables(resource types.Resource) int64 {
+	var total int64
+	for _, source := range u.Sources[resource] {
+		if source.Consumable {
+			total += source.Amount
+		}
+	}
+	return total
+}
+
+// Regen returns the amount of the resource gained from spells and talents.
+func (u *UnitResources) Regen(resource types.Resource) int64 {
+	return u.Gained[resource] - u.Consumables(resource)
+}
@@ -0,0 +1,53 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/Emyrk/chronicle/golang/internal/ptr"
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResources(t *testing.T) {
+	t.Parallel()
+
+	gain := func(caster guid.GUID, amount int32, resource types.Resource, spell string) messages.ResourceChange {
+		return messages.ResourceChange{
+			MessageBase: at(1),
+			Target:      testPriest,
+			Amount:      amount,
+			Resource:    resource,
+			Caster:      ptr.Ref(caster),
+			SpellName:   ptr.Ref(spell),
+			Direction:   "gains",
+		}
+	}
+
+	r := NewResources()
+	for _, m := range []messages.ResourceChange{
+		gain(testPriest, 2000, types.ResourceMana, "Restore Mana"),
+		gain(testPriest, 1200, types.ResourceMana, "Dark Rune"),
+		gain(testWarrior, 500, types.ResourceMana, "Innervate"),
+		gain(testWarrior, 500, types.ResourceMana, "Innervate"),
+		gain(testPriest, 100, types.ResourceEnergy, "Restore Energy"),
+	} {
+		r.Process(m)
+	}
+	r.Process(messages.ResourceChange{MessageBase: at(2), Target: testPriest, Amount: 50, Resource: types.ResourceMana, Direction: "loses"})
+
+	unit := r.Units[testPriest]
+	require.Equal(t, int64(4200), unit.Gained[types.ResourceMana])
+	require.Equal(t, int64(50), unit.Lost[types.ResourceMana])
+	require.Equal(t, int64(3200), unit.Consumables(types.ResourceMana))
+	require.Equal(t, int64(1000), unit.Regen(types.ResourceMana))
+	require.Equal(t, int64(100), unit.Consumables(types.ResourceEnergy))
+
+	sources := unit.SourceRanking(types.ResourceMana)
+	require.Len(t, sources, 3)
+	require.Equal(t, "Restore Mana", sources[0].SpellName)
+	require.Equal(t, "Innervate", sources[2].SpellName)
+	require.Equal(t, 2, sources[2].Count)
+	require.Equal(t, int64(1000), sources[2].ByCaster[testWarrior])
+}
@@ -3,9 +3,12 @@ package state
 import (
 	"fmt"
 	"log/slog"
+	"time"
 
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
 	"github.com/Emyrk/chronicle/golang/wowlogs/types"
 	"github.com/Emyrk/chronicle/golang/wowlogs/types/zone"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/encounters"
 	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
 )
 
@@ -18,18 +21,60 @@ type State struct {
 	// Units holds information about all units seen so far.
 	// Friendly/Foe/Relationships, etc.
 	Units *Units
+	// Owners tracks who owns pets, totems and guardians.
+	Owners *Owners
+	// Auras tracks buffs and debuffs on all units.
+	Auras *Auras
+	// History keeps recent events per unit for death recaps.
+	History *History
+	// Loot is every item looted, attributed to boss kills.
+	Loot *LootLog
+	// Consumables is every consumable used by a player.
+	Consumables *ConsumableLog
 
 	Fights *Fights
+
+	// avoidable is the avoidable damage catalog, keyed by encounter name.
+	avoidable map[string][]string
 }
 
-func NewState(logger *slog.Logger, me types.Unit) *State {
+// Option configures a State.
+type Option func(s *State)
+
+// WithCombatTimeout sets how long a fight can go without damage or heals
+// before it is ended. A zero value disables the timeout.
+func WithCombatTimeout(timeout time.Duration) Option {
+	return func(s *State) {
+		s.Fights.Timeout = timeout
+	}
+}
+
+// WithAvoidableDamage replaces the catalog of avoidable damage. The catalog is
+// keyed by the encounter name, with encounters.AnyEncounter applying to every
+// fight.
+func WithAvoidableDamage(catalog map[string][]string) Option {
+	return func(s *State) {
+		s.avoidable = catalog
+	}
+}
+
+func NewState(logger *slog.Logger, me types.Unit, opts ...Option) *State {
 	s := &State{
 		logger:      logger,
 		Me:          me,
 		Units:       NewUnits(),
+		Owners:      NewOwners(),
+		Auras:       NewAuras(),
+		History:     NewHistory(),
+		Loot:        NewLootLog(),
+		Consumables: NewConsumableLog(),
 		CurrentZone: zone.Zone{},
+		avoidable:   encounters.AvoidableDamage,
 	}
 	s.Fights = NewFights(s)
+	for _, opt := range opts {
+		opt(s)
+	}
 	return s
 }
 
@@ -38,26 +83,52 @@ func (s *State) Process(m messages.Message) error {
 	case messages.Zone:
 		s.Zone(typed)
 	case messages.Damage:
-		//s.Damage(typed)
+		s.History.Record(typed.Target, typed)
+	case messages.FallDamage:
+		s.History.Record(typed.Target, typed)
+	case messages.Heal:
+		s.History.Record(typed.Target, typed)
 	case messages.Cast:
-		//s.CastV2(typed)
+		s.Owners.Cast(typed)
+		s.Consumables.Cast(typed, s.CurrentZone)
 	case messages.Combatant:
 		s.Combatant(typed)
 	case messages.Unit:
 		s.Unit(typed)
+	case messages.Aura:
+		s.Auras.Process(typed)
+		s.Consumables.Aura(typed, s.CurrentZone)
+		s.History.Record(typed.Target, typed)
 	case messages.Slain:
-		//s.Slain(typed)
+		// Dead units lose all their auras
+		s.Auras.ClearUnit(typed.Date(), typed.Victim)
+	case messages.Loot:
+		s.Loot.Loot(typed, s.Units, s.Fights)
 	}
 
 	return s.Fights.Process(m)
 }
 
+// IsFriendly returns true if the unit is on the player's side. Units without
+// info are friendly if they are owned or a player.
+func (s *State) IsFriendly(id guid.GUID) bool {
+	if info, ok := s.Units.Get(id); ok {
+		return info.CanCooperate
+	}
+	if _, ok := s.Owners.Get(id); ok {
+		return true
+	}
+	return id.IsPlayer()
+}
+
 func (s *State) Combatant(c messages.Combatant) {
 	s.Units.UpdatePlayer(c.Combatant)
+	s.Owners.Combatant(c, s.Units)
 }
 
 func (s *State) Unit(u messages.Unit) {
 	s.Units.Update(u.Info)
+	s.Owners.Unit(u)
 }
 
 func (s *State) Zone(z messages.Zone) {
@@ -0,0 +1,176 @@
+package state
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/zone"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+)
+
+// DefaultSummaryTop is how many players are listed for damage and healing in
+// a fight summary.
+const DefaultSummaryTop = 10
+
+// FightFilter picks completed fights for a report. Zero values match every
+// fight.
+type FightFilter struct {
+	// Number is the fight number as printed in the reports, starting at 1.
+	Number int
+	// From and To select fights that overlap the time range.
+	From time.Time
+	To   time.Time
+}
+
+// Select returns the indexes of the completed fights that match the filter.
+func (fs *Fights) Select(filter FightFilter) []int {
+	var indexes []int
+	for i, fight := range fs.Fights {
+		if !fight.IsStarted() || !fight.IsDone() {
+			continue
+		}
+		if filter.Number > 0 && filter.Number != i+1 {
+			continue
+		}
+		if !filter.From.IsZero() && fight.End.Date().Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && fight.Start.Date().After(filter.To) {
+			continue
+		}
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+// Title is the encounter name, or the zone for trash fights.
+func (f *Fight) Title() string {
+	if f.Encounter != nil {
+		return fmt.Sprintf("%s (%s)", f.Encounter.Name, f.Encounter.Raid)
+	}
+	if f.CurrentZone.Name != "" {
+		return fmt.Sprintf("Trash (%s)", f.CurrentZone.Name)
+	}
+	return "Trash"
+}
+
+// Summary is a short readable report of the fight, for the terminal. Only the
+// top friendly players are listed for damage and healing, and only friendly
+// deaths are listed.
+func (f *Fight) Summary(top int) string {
+	var b strings.Builder
+
+	b.WriteString(f.Title())
+	if f.Outcome != OutcomeUnknown {
+		b.WriteString(fmt.Sprintf(" - %s", f.Outcome))
+	}
+	b.WriteString("\n")
+	if f.IsStarted() && f.IsDone() {
+		b.WriteString(fmt.Sprintf("%s - %s (%s)\n", f.Start.Date().Format("15:04:05"), f.End.Date().Format("15:04:05"), f.Duration().Round(time.Second)))
+	}
+
+	damage := f.DamageByOwner()
+	f.writeTop(&b, "Damage", damage, "dps", top)
+	healing := f.HealingByOwner()
+	f.writeTop(&b, "Healing", healing, "hps", top)
+
+	var deaths []DeathRecap
+	for _, death := range f.Deaths {
+		if f.s.IsFriendly(death.Victim) {
+			deaths = append(deaths, death)
+		}
+	}
+	if len(deaths) > 0 {
+		b.WriteString(fmt.Sprintf("\nDeaths: %d\n", len(deaths)))
+		for _, death := range deaths {
+			killer := "Unknown"
+			if death.Killer != nil {
+				killer = f.getUnitName(*death.Killer)
+			}
+			if d, ok := death.KillingBlow.(messages.Damage); ok && d.SpellName != nil {
+				killer = fmt.Sprintf("%s (%s)", killer, *d.SpellName)
+			}
+			b.WriteString(fmt.Sprintf("  %s %-20s %s\n", death.Slain.Date().Format("15:04:05"), f.getUnitName(death.Victim), killer))
+		}
+	}
+
+	if avoidable := f.AvoidableDamage(); len(avoidable) > 0 {
+		b.WriteString("\nAvoidable Damage:\n")
+		for _, hit := range avoidable {
+			b.WriteString(fmt.Sprintf("  %-20s %-20s %3d hits %10d\n", f.getUnitName(hit.Player), hit.SpellName, hit.Hits, hit.Total))
+		}
+	}
+
+	kicks := f.Interrupts.Interrupts
+	if len(kicks) > 0 || len(f.Interrupts.Uninterrupted) > 0 {
+		b.WriteString(fmt.Sprintf("\nInterrupts: %d (%d hostile casts not interrupted)\n", len(kicks), len(f.Interrupts.Uninterrupted)))
+		for _, kick := range kicks {
+			b.WriteString(fmt.Sprintf("  %s %-20s %s's %s\n", kick.At.Format("15:04:05"), f.getUnitName(kick.Caster), f.getUnitName(kick.Target), kick.SpellName))
+		}
+	}
+
+	return b.String()
+}
+
+// ConsumablesSummary is a readable report of the consumables every player
+// used in each raid instance, for the terminal.
+func (s *State) ConsumablesSummary() string {
+	var b strings.Builder
+
+	var last zone.Zone
+	for i, instance := range s.Consumables.ByInstance() {
+		if i == 0 || !instance.Zone.Equal(last) {
+			last = instance.Zone
+			name := instance.Zone.Name
+			if name == "" {
+				name = "Unknown zone"
+			}
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			if instance.Zone.InstanceID > 0 {
+				name = fmt.Sprintf("%s (Instance %d)", name, instance.Zone.InstanceID)
+			}
+			b.WriteString(fmt.Sprintf("%s\n", name))
+		}
+
+		player := instance.Player.String()
+		if info, ok := s.Units.Get(instance.Player); ok && info.Name != "" {
+			player = info.Name
+		}
+		var items []string
+		for _, item := range slices.Sorted(maps.Keys(instance.Items)) {
+			items = append(items, fmt.Sprintf("%s x%d", item, instance.Items[item]))
+		}
+		b.WriteString(fmt.Sprintf("  %-20s %s\n", player, strings.Join(items, ", ")))
+	}
+	return b.String()
+}
+
+// writeTop writes the top friendly units of the meter.
+func (f *Fight) writeTop(b *strings.Builder, title string, meter *Meter, rate string, top int) {
+	var total int64
+	var rows []UnitAmount
+	for _, row := range meter.DoneRanking() {
+		if row.Total == 0 || !f.s.IsFriendly(row.Unit) {
+			continue
+		}
+		total += row.Total
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	b.WriteString(fmt.Sprintf("\n%s: %d\n", title, total))
+	for i, row := range rows {
+		if top > 0 && i >= top {
+			break
+		}
+		share := float64(row.Total) / float64(total) * 100
+		b.WriteString(fmt.Sprintf("  %2d. %-20s %10d %5.1f%% %8.1f %s\n", i+1, f.getUnitName(row.Unit), row.Total, share, meter.PerSecond(row.Unit, f.Duration()), rate))
+	}
+}
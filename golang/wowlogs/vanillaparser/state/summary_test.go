@@ -0,0 +1,50 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/Emyrk/chronicle/golang/internal/ptr"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFightSummary(t *testing.T) {
+	t.Parallel()
+
+	s := newTestState(t)
+	process(t, s,
+		messages.Damage{MessageBase: at(1), Caster: testWarrior, Target: testBoss, SpellName: ptr.Ref("Heroic Strike"), Amount: 400, HitType: types.HitTypeHit},
+		messages.Damage{MessageBase: at(2), Caster: testBoss, Target: testWarrior, Amount: 200, HitType: types.HitTypeHit},
+		messages.Heal{MessageBase: at(3), Caster: testPriest, Target: testWarrior, SpellName: "Flash Heal", Amount: 600, HitType: types.HitTypeHit},
+		messages.Damage{MessageBase: at(5), Caster: testWarrior, Target: testBoss, Amount: 600, HitType: types.HitTypeHit},
+		messages.Slain{MessageBase: at(5), Victim: testBoss, Killer: ptr.Ref(testWarrior)},
+		// A second pull, 10 minutes later
+		messages.Damage{MessageBase: at(600), Caster: testWarrior, Target: testBoss, Amount: 100, HitType: types.HitTypeHit},
+		messages.Damage{MessageBase: at(600.5), Caster: testPriest, Target: testBoss, SpellName: ptr.Ref("Smite"), Amount: 100, HitType: types.HitTypeHit},
+		messages.Damage{MessageBase: at(600.7), Caster: testBoss, Target: testPriest, SpellName: ptr.Ref("Poison Charge"), Amount: 300, HitType: types.HitTypeHit},
+		messages.Slain{MessageBase: at(601), Victim: testWarrior, Killer: ptr.Ref(testBoss)},
+		messages.Slain{MessageBase: at(602), Victim: testBoss, Killer: ptr.Ref(testPriest)},
+	)
+
+	fights := s.Fights
+	require.Equal(t, []int{0, 1}, fights.Select(FightFilter{}))
+	require.Equal(t, []int{1}, fights.Select(FightFilter{Number: 2}))
+	require.Equal(t, []int{0}, fights.Select(FightFilter{To: at(60).Date()}))
+	require.Equal(t, []int{1}, fights.Select(FightFilter{From: at(60).Date()}))
+	require.Empty(t, fights.Select(FightFilter{Number: 3}))
+
+	summary := fights.Fights[0].Summary(DefaultSummaryTop)
+	require.Contains(t, summary, "Trash - kill")
+	require.Contains(t, summary, "Damage: 1000")
+	require.Contains(t, summary, "Tankman")
+	require.Contains(t, summary, "Healing: 600")
+	require.NotContains(t, summary, "Gray Bear", "hostile damage is not listed")
+	require.NotContains(t, summary, "Deaths")
+	require.NotContains(t, summary, "Avoidable Damage")
+
+	summary = fights.Fights[1].Summary(DefaultSummaryTop)
+	require.Contains(t, summary, "Deaths: 1")
+	require.Contains(t, summary, "Avoidable Damage")
+	require.Contains(t, summary, "Poison Charge")
+}
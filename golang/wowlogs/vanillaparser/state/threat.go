@@ -0,0 +1,447 @@
+package state
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+)
+
+// Threat constants are approximations of the vanilla threat formulas.
+// The logs do not include overhealing, talents or spell ranks, so the
+// estimate is only as good as these numbers.
+const (
+	// ThreatPerHeal is the threat per point healed, split among all enemies.
+	ThreatPerHeal = 0.5
+	// ThreatPerRage is the threat per rage gained, split among all enemies.
+	ThreatPerRage = 5.0
+	// ThreatPerMana is the threat per mana gained, split among all enemies.
+	ThreatPerMana = 0.5
+	// ThreatPullRatio is how much threat over the current holder is needed to
+	// pull aggro. Melee needs 110% and ranged 130%, range is not in the logs.
+	ThreatPullRatio = 1.1
+	// ThreatFade is the threat removed by Fade while it is active.
+	ThreatFade = 820
+)
+
+// threatModifiers are auras that multiply all threat done by the unit.
+var threatModifiers = map[string]float64{
+	"Defensive Stance":              1.3,
+	"Battle Stance":                 0.8,
+	"Berserker Stance":              0.8,
+	"Bear Form":                     1.3,
+	"Dire Bear Form":                1.3,
+	"Cat Form":                      0.71,
+	"Blessing of Salvation":         0.7,
+	"Greater Blessing of Salvation": 0.7,
+	"Tranquil Air":                  0.8,
+}
+
+// threatDamageBonus is the flat threat added by a spell when it hits, on top
+// of its damage.
+var threatDamageBonus = map[string]float64{
+	"Heroic Strike": 175,
+	"Revenge":       355,
+	"Shield Slam":   250,
+	"Cleave":        100,
+}
+
+// threatCasts is the flat threat of spells that do no damage. Negative values
+// remove threat.
+var threatCasts = map[string]float64{
+	"Sunder Armor": 261,
+	"Feint":        -800,
+	"Cower":        -600,
+}
+
+// tauntSpells set the caster's threat to the highest threat on the target.
+var tauntSpells = map[string]bool{
+	"Taunt": true,
+	"Growl": true,
+}
+
+// Threat estimates the threat tables of every enemy in a fight.
+type Threat struct {
+	s *State
+	// Tables is keyed by the enemy.
+	Tables map[guid.GUID]*ThreatTable
+	// faded is the threat removed from each unit by Fade, keyed by the enemy.
+	faded map[guid.GUID]map[guid.GUID]float64
+}
+
+// ThreatTable is the threat every friendly unit has on a single enemy.
+type ThreatTable struct {
+	Enemy  guid.GUID
+	Threat map[guid.GUID]float64
+	// Holder is the unit estimated to have aggro.
+	Holder guid.GUID
+	// Events is every change to the table, oldest first. Replaying the events
+	// gives the table at any point in the fight.
+	Events []ThreatEvent
+	// AggroChanges are the times the holder changed.
+	AggroChanges []AggroChange
+	// Dead enemies no longer share heal and resource threat.
+	Dead bool
+}
+
+// ThreatEvent is a single change of threat on a table.
+type ThreatEvent struct {
+	At    time.Time
+	Unit  guid.GUID
+	Spell string
+	Delta float64
+	// Total is the unit's threat after the change.
+	Total float64
+}
+
+// AggroChange is an enemy switching from one unit to another.
+type AggroChange struct {
+	At    time.Time
+	Enemy guid.GUID
+	// From is zero for the initial pull.
+	From guid.GUID
+	To   guid.GUID
+	// Taunt is true if the change was forced by a taunt.
+	Taunt bool
+}
+
+// ThreatAmount is the threat of a single unit.
+type ThreatAmount struct {
+	Unit   guid.GUID
+	Threat float64
+}
+
+func NewThreat(s *State) *Threat {
+	return &Threat{
+		s:      s,
+		Tables: make(map[guid.GUID]*ThreatTable),
+		faded:  make(map[guid.GUID]map[guid.GUID]float64),
+	}
+}
+
+func (t *Threat) Process(m messages.Message) {
+	switch typed := m.(type) {
+	case messages.Damage:
+		t.Damage(typed)
+	case messages.Heal:
+		t.Heal(typed)
+	case messages.ResourceChange:
+		t.Resource(typed)
+	case messages.Cast:
+		t.Cast(typed)
+	case messages.Aura:
+		t.Aura(typed)
+	case messages.Slain:
+		t.Slain(typed)
+	}
+}
+
+func (t *Threat) Damage(d messages.Damage) {
+	casterFriendly, targetFriendly := t.s.IsFriendly(d.Caster), t.s.IsFriendly(d.Target)
+	if !casterFriendly && targetFriendly {
+		// Enemies attacking are in combat, even if no threat is done yet
+		t.table(d.Caster)
+		return
+	}
+	if !casterFriendly || targetFriendly {
+		return
+	}
+
+	spell := damageSpellName(d)
+	amount := float64(d.Amount)
+	if d.Amount > 0 {
+		amount += threatDamageBonus[spell]
+	}
+	t.add(d.Date(), d.Target, d.Caster, spell, amount*t.modifier(d.Caster, damageSchool(d)))
+}
+
+// Heal threat is split among all enemies in combat.
+func (t *Threat) Heal(h messages.Heal) {
+	if !t.s.IsFriendly(h.Caster) || !t.s.IsFriendly(h.Target) {
+		return
+	}
+	amount := float64(h.Amount) * ThreatPerHeal * t.modifier(h.Caster, types.HolySchool)
+	t.split(h.Date(), h.Caster, h.SpellName, amount)
+}
+
+// Resource threat is split among all enemies in combat, and is not affected
+// by threat modifiers.
+func (t *Threat) Resource(r messages.ResourceChange) {
+	if r.Direction != "gains" || !t.s.IsFriendly(r.Target) {
+		return
+	}
+
+	var per float64
+	switch r.Resource {
+	case types.ResourceRage:
+		per = ThreatPerRage
+	case types.ResourceMana:
+		per = ThreatPerMana
+	default:
+		return
+	}
+
+	spell := ""
+	if r.SpellName != nil {
+		spell = *r.SpellName
+	}
+	t.split(r.Date(), r.Target, spell, float64(r.Amount)*per)
+}
+
+// Cast handles spells with threat but no damage, like Sunder Armor, and taunts.
+func (t *Threat) Cast(c messages.Cast) {
+	if c.Action != types.CastActionsCasts {
+		return
+	}
+	caster := c.Caster.Gid
+	if !t.s.IsFriendly(caster) {
+		return
+	}
+
+	spell := c.Spell.Name
+	if c.Target == nil {
+		// Threat reductions without a target apply to every enemy
+		if flat := threatCasts[spell]; flat < 0 {
+			for enemy, table := range t.Tables {
+				if !table.Dead {
+					t.add(c.Date(), enemy, caster, spell, flat)
+				}
+			}
+		}
+		return
+	}
+
+	target := c.Target.Gid
+	if t.s.IsFriendly(target) {
+		return
+	}
+
+	if tauntSpells[spell] {
+		t.taunt(c.Date(), target, caster, spell)
+		return
+	}
+
+	flat, ok := threatCasts[spell]
+	if !ok {
+		return
+	}
+	if flat > 0 {
+		flat *= t.modifier(caster, types.PhysicalSchool)
+	}
+	t.add(c.Date(), target, caster, spell, flat)
+}
+
+// Aura handles Fade, which removes threat until it fades.
+func (t *Threat) Aura(a messages.Aura) {
+	if a.SpellName != "Fade" || !t.s.IsFriendly(a.Target) {
+		return
+	}
+
+	switch a.Application {
+	case types.AuraApplicationGains:
+		for enemy, table := range t.Tables {
+			current := table.Threat[a.Target]
+			removed := min(current, ThreatFade)
+			if removed <= 0 {
+				continue
+			}
+			if t.faded[enemy] == nil {
+				t.faded[enemy] = make(map[guid.GUID]float64)
+			}
+			t.faded[enemy][a.Target] = removed
+			t.add(a.Date(), enemy, a.Target, a.SpellName, -removed)
+		}
+	case types.AuraApplicationFades, types.AuraApplicationRemoved:
+		for enemy, units := range t.faded {
+			removed, ok := units[a.Target]
+			if !ok {
+				continue
+			}
+			delete(units, a.Target)
+			if !t.Tables[enemy].Dead {
+				t.add(a.Date(), enemy, a.Target, a.SpellName, removed)
+			}
+		}
+	}
+}
+
+// Slain removes dead enemies from combat, and wipes the threat of dead
+// friendly units.
+func (t *Threat) Slain(s messages.Slain) {
+	if table, ok := t.Tables[s.Victim]; ok {
+		table.Dead = true
+		return
+	}
+
+	for enemy, table := range t.Tables {
+		current, ok := table.Threat[s.Victim]
+		if !ok || current == 0 || table.Dead {
+			continue
+		}
+		t.add(s.Date(), enemy, s.Victim, "", -current)
+	}
+}
+
+func (t *Threat) table(enemy guid.GUID) *ThreatTable {
+	table, ok := t.Tables[enemy]
+	if !ok {
+		table = &ThreatTable{
+			Enemy:  enemy,
+			Threat: make(map[guid.GUID]float64),
+		}
+		t.Tables[enemy] = table
+	}
+	return table
+}
+
+func (t *Threat) split(ts time.Time, unit guid.GUID, spell string, amount float64) {
+	var active []guid.GUID
+	for enemy, table := range t.Tables {
+		if !table.Dead && !t.s.IsFriendly(enemy) {
+			active = append(active, enemy)
+		}
+	}
+	if len(active) == 0 {
+		return
+	}
+
+	each := amount / float64(len(active))
+	for _, enemy := range active {
+		t.add(ts, enemy, unit, spell, each)
+	}
+}
+
+func (t *Threat) add(ts time.Time, enemy, unit guid.GUID, spell string, delta float64) {
+	if delta == 0 {
+		return
+	}
+	table := t.table(enemy)
+	total := max(table.Threat[unit]+delta, 0)
+	table.Threat[unit] = total
+	table.Events = append(table.Events, ThreatEvent{
+		At:    ts,
+		Unit:  unit,
+		Spell: spell,
+		Delta: delta,
+		Total: total,
+	})
+	table.checkAggro(ts)
+}
+
+func (t *Threat) taunt(ts time.Time, enemy, unit guid.GUID, spell string) {
+	table := t.table(enemy)
+	var highest float64
+	for _, threat := range table.Threat {
+		highest = max(highest, threat)
+	}
+
+	current := table.Threat[unit]
+	if highest > current {
+		table.Threat[unit] = highest
+		table.Events = append(table.Events, ThreatEvent{
+			At:    ts,
+			Unit:  unit,
+			Spell: spell,
+			Delta: highest - current,
+			Total: highest,
+		})
+	}
+
+	if table.Holder != unit {
+		table.AggroChanges = append(table.AggroChanges, AggroChange{
+			At:    ts,
+			Enemy: enemy,
+			From:  table.Holder,
+			To:    unit,
+			Taunt: true,
+		})
+		table.Holder = unit
+	}
+}
+
+// checkAggro moves aggro to the unit with the most threat, if they are over
+// the pull ratio of the current holder.
+func (table *ThreatTable) checkAggro(ts time.Time) {
+	top := table.Ranking()
+	if len(top) == 0 || top[0].Unit == table.Holder {
+		return
+	}
+
+	holderThreat := table.Threat[table.Holder]
+	if !table.Holder.IsZero() && holderThreat > 0 && top[0].Threat <= holderThreat*ThreatPullRatio {
+		return
+	}
+	if top[0].Threat <= 0 {
+		return
+	}
+
+	table.AggroChanges = append(table.AggroChanges, AggroChange{
+		At:    ts,
+		Enemy: table.Enemy,
+		From:  table.Holder,
+		To:    top[0].Unit,
+	})
+	table.Holder = top[0].Unit
+}
+
+// Ranking returns the current threat of every unit on the table, highest first.
+func (table *ThreatTable) Ranking() []ThreatAmount {
+	ranking := make([]ThreatAmount, 0, len(table.Threat))
+	for unit, threat := range table.Threat {
+		ranking = append(ranking, ThreatAmount{Unit: unit, Threat: threat})
+	}
+	slices.SortFunc(ranking, func(a, b ThreatAmount) int {
+		if c := cmp.Compare(b.Threat, a.Threat); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Unit, b.Unit)
+	})
+	return ranking
+}
+
+// At returns the threat of every unit on the table at the given time.
+func (table *ThreatTable) At(ts time.Time) map[guid.GUID]float64 {
+	threat := make(map[guid.GUID]float64)
+	for _, e := range table.Events {
+		if e.At.After(ts) {
+			break
+		}
+		threat[e.Unit] = e.Total
+	}
+	return threat
+}
+
+// AggroChanges returns the aggro changes of every enemy, oldest first.
+func (t *Threat) AggroChanges() []AggroChange {
+	var changes []AggroChange
+	for _, table := range t.Tables {
+		changes = append(changes, table.AggroChanges...)
+	}
+	slices.SortStableFunc(changes, func(a, b AggroChange) int {
+		if c := a.At.Compare(b.At); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Enemy, b.Enemy)
+	})
+	return changes
+}
+
+// modifier is the product of all threat modifiers active on the unit.
+func (t *Threat) modifier(unit guid.GUID, school types.School) float64 {
+	mod := 1.0
+	if player, ok := t.s.Units.Players[unit]; ok && player.HeroClass == types.HeroClassesROGUE {
+		mod *= 0.71
+	}
+	for spell, m := range threatModifiers {
+		if t.s.Auras.Active(unit, spell) {
+			mod *= m
+		}
+	}
+	if school == types.HolySchool && t.s.Auras.Active(unit, "Righteous Fury") {
+		mod *= 1.6
+	}
+	return mod
+}
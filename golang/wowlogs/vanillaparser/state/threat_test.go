@@ -0,0 +1,143 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/Emyrk/chronicle/golang/internal/ptr"
+	"github.com/Emyrk/chronicle/golang/wowlogs/guid"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types"
+	"github.com/Emyrk/chronicle/golang/wowlogs/types/castv2"
+	"github.com/Emyrk/chronicle/golang/wowlogs/vanillaparser/messages"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThreat(t *testing.T) {
+	t.Parallel()
+
+	s := newTestState(t)
+	process(t, s,
+		messages.Aura{MessageBase: at(0), Target: testWarrior, SpellName: "Defensive Stance", Application: types.AuraApplicationGains},
+		// 100 damage in defensive stance is 130 threat
+		messages.Damage{MessageBase: at(1), Caster: testWarrior, Target: testBoss, Amount: 100, HitType: types.HitTypeHit},
+		messages.Damage{MessageBase: at(1.5), Caster: testBoss, Target: testWarrior, Amount: 100, HitType: types.HitTypeHit},
+		// 1000 healing is 500 threat
+		messages.Heal{MessageBase: at(2), Caster: testPriest, Target: testWarrior, SpellName: "Greater Heal", Amount: 1000, HitType: types.HitTypeHit},
+		messages.Cast{MessageBase: at(3), CastV2: castv2.CastV2{
+			Caster: types.Unit{Gid: testWarrior},
+			Action: types.CastActionsCasts,
+			Target: &types.Unit{Gid: testBoss},
+			Spell:  types.Spell{Name: "Taunt"},
+		}},
+	)
+
+	fight := s.Fights.CurrentFight
+	table := fight.Threat.Tables[testBoss]
+	require.NotNil(t, table)
+	require.InDelta(t, 500, table.Threat[testPriest], 0.001)
+	require.InDelta(t, 500, table.Threat[testWarrior], 0.001, "taunt matches the highest threat")
+	require.Equal(t, testWarrior, table.Holder)
+
+	changes := fight.Threat.AggroChanges()
+	require.Len(t, changes, 3)
+	require.True(t, changes[0].From.IsZero(), "initial pull")
+	require.Equal(t, testWarrior, changes[0].To)
+	require.Equal(t, testWarrior, changes[1].From)
+	require.Equal(t, testPriest, changes[1].To)
+	require.True(t, changes[2].Taunt)
+
+	// Threat at the time of the heal, before the taunt
+	before := table.At(at(2.5).Date())
+	require.InDelta(t, 130, before[testWarrior], 0.001)
+
+	// Dead units lose all threat
+	process(t, s, messages.Slain{MessageBase: at(4), Victim: testPriest, Killer: ptr.Ref(testBoss)})
+	require.Zero(t, table.Threat[testPriest])
+}
+
+func TestThreatModifiers(t *testing.T) {
+	t.Parallel()
+
+	aura := func(sec float64, unit guid.GUID, spell string, application types.AuraApplication) messages.Aura {
+		return messages.Aura{MessageBase: at(sec), Target: unit, SpellName: spell, Application: application}
+	}
+	gains := func(unit guid.GUID, spell string) messages.Aura {
+		return aura(0.5, unit, spell, types.AuraApplicationGains)
+	}
+	strike := func(sec float64, spell string) messages.Damage {
+		d := messages.Damage{MessageBase: at(sec), Caster: testWarrior, Target: testBoss, Amount: 1000, HitType: types.HitTypeHit}
+		if spell != "" {
+			d.SpellName = ptr.Ref(spell)
+		}
+		return d
+	}
+	cast := func(sec float64, caster guid.GUID, target *guid.GUID, spell string) messages.Cast {
+		c := messages.Cast{MessageBase: at(sec), CastV2: castv2.CastV2{
+			Caster: types.Unit{Gid: caster},
+			Action: types.CastActionsCasts,
+			Spell:  types.Spell{Name: spell},
+		}}
+		if target != nil {
+			c.Target = &types.Unit{Gid: *target}
+		}
+		return c
+	}
+	boss := ptr.Ref(testBoss)
+
+	for _, tc := range []struct {
+		name string
+		msgs []messages.Message
+		unit guid.GUID
+		want float64
+	}{
+		{name: "NoModifier", msgs: []messages.Message{strike(1, "")}, unit: testWarrior, want: 1000},
+
+		// Modifiers multiply all threat done
+		{name: "DefensiveStance", msgs: []messages.Message{gains(testWarrior, "Defensive Stance"), strike(1, "")}, unit: testWarrior, want: 1300},
+		{name: "BattleStance", msgs: []messages.Message{gains(testWarrior, "Battle Stance"), strike(1, "")}, unit: testWarrior, want: 800},
+		{name: "BerserkerStance", msgs: []messages.Message{gains(testWarrior, "Berserker Stance"), strike(1, "")}, unit: testWarrior, want: 800},
+		{name: "BearForm", msgs: []messages.Message{gains(testWarrior, "Bear Form"), strike(1, "")}, unit: testWarrior, want: 1300},
+		{name: "DireBearForm", msgs: []messages.Message{gains(testWarrior, "Dire Bear Form"), strike(1, "")}, unit: testWarrior, want: 1300},
+		{name: "CatForm", msgs: []messages.Message{gains(testWarrior, "Cat Form"), strike(1, "")}, unit: testWarrior, want: 710},
+		{name: "Salvation", msgs: []messages.Message{gains(testWarrior, "Blessing of Salvation"), strike(1, "")}, unit: testWarrior, want: 700},
+		{name: "GreaterSalvation", msgs: []messages.Message{gains(testWarrior, "Greater Blessing of Salvation"), strike(1, "")}, unit: testWarrior, want: 700},
+		{name: "TranquilAir", msgs: []messages.Message{gains(testWarrior, "Tranquil Air"), strike(1, "")}, unit: testWarrior, want: 800},
+		{name: "SalvationInDefensiveStance", msgs: []messages.Message{gains(testWarrior, "Defensive Stance"), gains(testWarrior, "Blessing of Salvation"), strike(1, "")}, unit: testWarrior, want: 910},
+		{name: "ModifierFaded", msgs: []messages.Message{gains(testWarrior, "Blessing of Salvation"), aura(0.8, testWarrior, "Blessing of Salvation", types.AuraApplicationFades), strike(1, "")}, unit: testWarrior, want: 1000},
+
+		// Heals are split among the enemies, Righteous Fury only affects holy
+		{name: "Heal", msgs: []messages.Message{strike(1, ""), messages.Heal{MessageBase: at(2), Caster: testPriest, Target: testWarrior, SpellName: "Heal", Amount: 1000, HitType: types.HitTypeHit}}, unit: testPriest, want: 500},
+		{name: "RighteousFuryHeal", msgs: []messages.Message{gains(testPriest, "Righteous Fury"), strike(1, ""), messages.Heal{MessageBase: at(2), Caster: testPriest, Target: testWarrior, SpellName: "Holy Light", Amount: 1000, HitType: types.HitTypeHit}}, unit: testPriest, want: 800},
+		{name: "RighteousFuryPhysical", msgs: []messages.Message{gains(testWarrior, "Righteous Fury"), strike(1, "")}, unit: testWarrior, want: 1000},
+
+		// Flat threat on top of the damage, affected by modifiers
+		{name: "HeroicStrike", msgs: []messages.Message{strike(1, "Heroic Strike")}, unit: testWarrior, want: 1175},
+		{name: "Revenge", msgs: []messages.Message{strike(1, "Revenge")}, unit: testWarrior, want: 1355},
+		{name: "ShieldSlam", msgs: []messages.Message{strike(1, "Shield Slam")}, unit: testWarrior, want: 1250},
+		{name: "Cleave", msgs: []messages.Message{strike(1, "Cleave")}, unit: testWarrior, want: 1100},
+		{name: "HeroicStrikeInDefensiveStance", msgs: []messages.Message{gains(testWarrior, "Defensive Stance"), strike(1, "Heroic Strike")}, unit: testWarrior, want: 1527.5},
+		{name: "SunderArmor", msgs: []messages.Message{strike(1, ""), cast(2, testWarrior, boss, "Sunder Armor")}, unit: testWarrior, want: 1261},
+		{name: "SunderArmorInDefensiveStance", msgs: []messages.Message{gains(testWarrior, "Defensive Stance"), strike(1, ""), cast(2, testWarrior, boss, "Sunder Armor")}, unit: testWarrior, want: 1639.3},
+
+		// Threat drops are not affected by modifiers, and never go below 0
+		{name: "Feint", msgs: []messages.Message{strike(1, ""), cast(2, testWarrior, boss, "Feint")}, unit: testWarrior, want: 200},
+		{name: "FeintWithoutTarget", msgs: []messages.Message{strike(1, ""), cast(2, testWarrior, nil, "Feint")}, unit: testWarrior, want: 200},
+		{name: "FeintInDefensiveStance", msgs: []messages.Message{gains(testWarrior, "Defensive Stance"), strike(1, ""), cast(2, testWarrior, boss, "Feint")}, unit: testWarrior, want: 500},
+		{name: "Cower", msgs: []messages.Message{strike(1, ""), cast(2, testWarrior, boss, "Cower")}, unit: testWarrior, want: 400},
+		{name: "CowerTwice", msgs: []messages.Message{strike(1, ""), cast(2, testWarrior, boss, "Cower"), cast(3, testWarrior, boss, "Cower")}, unit: testWarrior, want: 0},
+		{name: "Fade", msgs: []messages.Message{strike(1, ""), aura(2, testWarrior, "Fade", types.AuraApplicationGains)}, unit: testWarrior, want: 180},
+		{name: "FadeEnds", msgs: []messages.Message{strike(1, ""), aura(2, testWarrior, "Fade", types.AuraApplicationGains), aura(3, testWarrior, "Fade", types.AuraApplicationFades)}, unit: testWarrior, want: 1000},
+		{name: "FadeBelowZero", msgs: []messages.Message{strike(1, ""), messages.Heal{MessageBase: at(2), Caster: testPriest, Target: testWarrior, SpellName: "Heal", Amount: 1000, HitType: types.HitTypeHit}, aura(3, testPriest, "Fade", types.AuraApplicationGains)}, unit: testPriest, want: 0},
+		{name: "FadeBelowZeroEnds", msgs: []messages.Message{strike(1, ""), messages.Heal{MessageBase: at(2), Caster: testPriest, Target: testWarrior, SpellName: "Heal", Amount: 1000, HitType: types.HitTypeHit}, aura(3, testPriest, "Fade", types.AuraApplicationGains), aura(4, testPriest, "Fade", types.AuraApplicationFades)}, unit: testPriest, want: 500},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newTestState(t)
+			process(t, s, tc.msgs...)
+
+			table := s.Fights.CurrentFight.Threat.Tables[testBoss]
+			require.NotNil(t, table)
+			require.InDelta(t, tc.want, table.Threat[tc.unit], 0.001)
+		})
+	}
+}
@@ -24,6 +24,17 @@ func (us *Units) Get(gid guid.GUID) (unitinfo.Info, bool) {
 	return u, ok
 }
 
+// PlayerByName returns the guid of the player with the given name. Some lines
+// only include the player's name.
+func (us *Units) PlayerByName(name string) (guid.GUID, bool) {
+	for id, info := range us.Info {
+		if info.Name == name && id.IsPlayer() {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
 func (us *Units) Update(u unitinfo.Info) {
 	us.Info[u.Guid] = u
 }
@@ -158,13 +158,13 @@ function displayResults(stateJson) {
 function createFightsDisplay(state) {
     const fightsContainer = document.getElementById('fightsContainer');
     
-    if (!state.Fights || !state.Fights.Fights || state.Fights.Fights.length === 0) {
+    if (!state.fights || state.fights.length === 0) {
         fightsContainer.innerHTML = '<div class="no-fights">No fights recorded in this log</div>';
         return;
     }
     
     // Filter completed fights only
-    const fights = state.Fights.Fights.filter(fight => fight.Start && fight.End);
+    const fights = state.fights.filter(fight => fight.done);
     
     if (fights.length === 0) {
         fightsContainer.innerHTML = '<div class="no-fights">No completed fights found</div>';
@@ -179,9 +179,15 @@ function createFightsDisplay(state) {
     summary.innerHTML = `<h3>🗡️ ${fights.length} Fight${fights.length !== 1 ? 's' : ''} Found</h3>`;
     fightsContainer.appendChild(summary);
     
+    // Index units by guid
+    const unitsDb = {};
+    for (const unit of state.units || []) {
+        unitsDb[unit.guid] = unit;
+    }
+    
     // Create fight cards
-    fights.forEach((fight, index) => {
-        const fightCard = createFightCard(fight, index + 1, state.Units);
+    fights.forEach(fight => {
+        const fightCard = createFightCard(fight, fight.number, unitsDb);
         fightsContainer.appendChild(fightCard);
     });
 }
@@ -190,15 +196,11 @@ function createFightCard(fight, fightNum, unitsDb) {
     const card = document.createElement('div');
     card.className = 'fight-card';
     
-    // Calculate duration
-    const startTime = new Date(fight.Start.Date);
-    const endTime = new Date(fight.End.Date);
-    const durationMs = endTime - startTime;
-    const duration = formatDuration(durationMs / 1000);
+    const duration = formatDuration(fight.duration_ms / 1000);
     
     // Get zone info
-    const zoneName = fight.CurrentZone?.Name || 'Unknown Zone';
-    const instanceId = fight.CurrentZone?.InstanceID || 0;
+    const zoneName = fight.encounter || fight.zone || 'Unknown Zone';
+    const instanceId = fight.instance_id || 0;
     
     // Categorize units
     const friendlyUnits = [];
@@ -206,26 +208,22 @@ function createFightCard(fight, fightNum, unitsDb) {
     const unknownUnits = [];
     
     // Process units in the fight
-    for (const [guid, unitInfo] of Object.entries(fight.Units || {})) {
-        if (fight.FriendlyActive && fight.FriendlyActive[guid] !== undefined) {
-            friendlyUnits.push(unitInfo);
-        } else if (fight.EnemiesActive && fight.EnemiesActive[guid] !== undefined) {
-            enemyUnits.push(unitInfo);
+    for (const guid of fight.participants || []) {
+        const unitInfo = unitsDb[guid];
+        if (!unitInfo) {
+            unknownUnits.push({ Name: guid });
+        } else if (unitInfo.friendly) {
+            friendlyUnits.push({ Name: unitInfo.name || guid });
+        } else {
+            enemyUnits.push({ Name: unitInfo.name || guid });
         }
     }
     
-    // Process unknown/remaining units
-    for (const guid of Object.keys(fight.UnknownActive || {})) {
-        unknownUnits.push({ Name: guid, Guid: guid });
-    }
-    
     // Get deaths
-    const deaths = [];
-    for (const [guid, deathTime] of Object.entries(fight.Deaths || {})) {
-        const unitInfo = fight.Units[guid] || unitsDb?.Info?.[guid];
-        const name = unitInfo?.Name || guid;
-        deaths.push({ name, time: new Date(deathTime) });
-    }
+    const deaths = (fight.deaths || []).map(death => ({
+        name: death.victim.name || death.victim.guid,
+        time: new Date(death.at),
+    }));
     
     card.innerHTML = `
         <div class="fight-header">